@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config files are decoded into a tree of *configObject, []any, string,
+// json.Number, bool and nil. The tree keeps keys in file order so a
+// converted config reads like the original, and bridges every format to
+// JSON, which parseConfig decodes.
+
+// configObject is a mapping that remembers the order of its keys.
+type configObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newConfigObject() *configObject {
+	return &configObject{values: make(map[string]any)}
+}
+
+func (o *configObject) get(key string) (any, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// set adds key, or replaces its value when it is already present.
+func (o *configObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *configObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// configCodec reads and writes one config file format.
+type configCodec interface {
+	name() string
+	decode(raw []byte) (any, error)
+	encode(tree any) ([]byte, error)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) name() string { return "json" }
+
+func (jsonCodec) decode(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	tree, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the top-level value")
+	}
+	return tree, nil
+}
+
+func (jsonCodec) encode(tree any) ([]byte, error) {
+	body, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := newConfigObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key.(string), value)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case '[':
+		items := []any{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		_, err := dec.Token()
+		return items, err
+	}
+	return nil, fmt.Errorf("unexpected %v", delim)
+}
+
+// configExtensions lists the supported config formats in the order they are
+// looked for when a directory holds more than one.
+var configExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// codecFor picks the codec from the extension of path. Unknown extensions
+// are JSON, the original format.
+func codecFor(path string) configCodec {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yamlCodec{}
+	case ".toml":
+		return tomlCodec{}
+	}
+	return jsonCodec{}
+}
+
+// codecByName resolves the format names accepted by zen config convert.
+func codecByName(name string) (configCodec, string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "json":
+		return jsonCodec{}, ".json", nil
+	case "yaml", "yml":
+		return yamlCodec{}, ".yaml", nil
+	case "toml":
+		return tomlCodec{}, ".toml", nil
+	}
+	return nil, "", fmt.Errorf("unknown config format %q: expected json, yaml or toml", name)
+}
+
+// configJSON converts raw, written in the format of path, to JSON.
+func configJSON(path string, raw []byte) ([]byte, error) {
+	codec := codecFor(path)
+	if _, ok := codec.(jsonCodec); ok {
+		return raw, nil
+	}
+	tree, err := codec.decode(raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tree)
+}
+
+// encodeConfigJSON writes the JSON body of a config in the format of path.
+func encodeConfigJSON(path string, body []byte) ([]byte, error) {
+	codec := codecFor(path)
+	if _, ok := codec.(jsonCodec); ok {
+		return body, nil
+	}
+	tree, err := jsonCodec{}.decode(body)
+	if err != nil {
+		return nil, err
+	}
+	return codec.encode(tree)
+}
+
+// findConfigFile returns the first of base.json, base.yaml, base.yml and
+// base.toml that exists in dir, or base.json when there is none.
+func findConfigFile(dir string, base string) string {
+	for _, ext := range configExtensions {
+		path := filepath.Join(dir, base+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return filepath.Join(dir, base+configExtensions[0])
+}
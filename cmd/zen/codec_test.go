@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"zen-cli/internal/zencli"
+)
+
+func TestFindConfigFilePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	if got, want := findConfigFile(dir, "config"), filepath.Join(dir, "config.json"); got != want {
+		t.Fatalf("unexpected default path: got %q want %q", got, want)
+	}
+
+	for _, name := range []string{"config.toml", "config.yml", "config.yaml", "config.json"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if got := findConfigFile(dir, "config"); got != path {
+			t.Fatalf("unexpected path: got %q want %q", got, path)
+		}
+	}
+}
+
+func TestDefaultConfigPathFindsYAML(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	want := filepath.Join(xdg, "zen-cli", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(want), 0o755); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := os.WriteFile(want, []byte("allowedApps: [Slack]\n"), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	got, err := defaultConfigPath()
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got != want {
+		t.Fatalf("unexpected path: got %q want %q", got, want)
+	}
+}
+
+func TestConfigFormatsLoadAlike(t *testing.T) {
+	bodies := map[string]string{
+		"config.json": `{"allowedApps": ["Slack"], "profiles": {"writing": {"allowedApps": ["Notes"]}}}`,
+		"config.yaml": "allowedApps:\n  - Slack\nprofiles:\n  writing:\n    allowedApps: [Notes]\n",
+		"config.toml": "allowedApps = [\"Slack\"]\n\n[profiles.writing]\nallowedApps = [\"Notes\"]\n",
+	}
+	for name, body := range bodies {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		opts, err := loadProfileFromConfig(path, "writing", true)
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %v", name, err)
+		}
+		if !reflect.DeepEqual(opts.AllowedApps, []string{"Notes"}) {
+			t.Fatalf("%s: unexpected allowed apps: %v", name, opts.AllowedApps)
+		}
+	}
+}
+
+func TestUpdateConfigFileKeepsFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("allowedApps = [\"Slack\"]\n"), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	err := updateConfigFile(path, func(cfg *configFile) error {
+		return cfg.setProfileOptions(defaultProfileName, zencli.Options{AllowedApps: []string{"Slack", "Notes"}})
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !strings.Contains(string(raw), `allowedApps = ["Slack", "Notes"]`) {
+		t.Fatalf("expected TOML config, got %s", raw)
+	}
+	opts, err := loadProfileFromConfig(path, defaultProfileName, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(opts.AllowedApps, []string{"Slack", "Notes"}) {
+		t.Fatalf("unexpected allowed apps: %v", opts.AllowedApps)
+	}
+}
+
+func TestReadConfigFileReportsYAMLErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("allowedApps:\n  - Slack\nallowedApps: []\n"), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	_, err := readConfigFile(path, true)
+	if err == nil || !strings.Contains(err.Error(), "line 3: duplicate key") {
+		t.Fatalf("expected duplicate key error, got %v", err)
+	}
+}
+
+func TestCodecByName(t *testing.T) {
+	codec, ext, err := codecByName("YML")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if codec.name() != "yaml" || ext != ".yaml" {
+		t.Fatalf("unexpected codec: got %s %s", codec.name(), ext)
+	}
+	if _, _, err := codecByName("ini"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+// currentConfigVersion is the config shape this build reads and writes.
+// Files without a version predate versioning and are version 0.
+const currentConfigVersion = 1
+
+// configMigration upgrades the raw top-level keys of a config by one
+// version, before the config is decoded.
+type configMigration func(raw map[string]json.RawMessage) error
+
+// configMigrations[v] upgrades a version v config to version v+1.
+var configMigrations = []configMigration{
+	migrateConfigV0,
+}
+
+// migrateConfigV0 upgrades unversioned configs. Version 1 kept their shape
+// and only added the version field.
+func migrateConfigV0(map[string]json.RawMessage) error {
+	return nil
+}
+
+const (
+	configActionPath     = "path"
+	configActionShow     = "show"
+	configActionEdit     = "edit"
+	configActionValidate = "validate"
+	configActionReset    = "reset"
+	configActionMigrate  = "migrate"
+	configActionConvert  = "convert"
+)
+
+// strictConfigEnv turns on strict parsing, which rejects unknown keys such
+// as a misspelled "alowedApps" instead of ignoring them.
+const strictConfigEnv = "ZEN_CONFIG_STRICT"
+
+func strictConfigFromEnv() bool {
+	strict, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv(strictConfigEnv)))
+	return strict
+}
+
+// parseConfig migrates raw to the current version and decodes it. It also
+// returns the version raw was written with.
+func parseConfig(raw []byte, strict bool) (configFile, int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return configFile{}, 0, err
+	}
+	if fields == nil {
+		fields = map[string]json.RawMessage{}
+	}
+
+	version := 0
+	if rawVersion, ok := fields["version"]; ok {
+		if err := json.Unmarshal(rawVersion, &version); err != nil || version < 0 {
+			return configFile{}, 0, fmt.Errorf("invalid version %s: expected a non-negative integer", rawVersion)
+		}
+	}
+	if version > currentConfigVersion {
+		return configFile{}, 0, fmt.Errorf("config version %d is newer than this zen-cli supports (%d)", version, currentConfigVersion)
+	}
+
+	for v := version; v < currentConfigVersion; v++ {
+		if err := configMigrations[v](fields); err != nil {
+			return configFile{}, 0, fmt.Errorf("failed to migrate config from version %d: %w", v, err)
+		}
+	}
+	fields["version"] = json.RawMessage(strconv.Itoa(currentConfigVersion))
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return configFile{}, 0, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(migrated))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	var cfg configFile
+	if err := dec.Decode(&cfg); err != nil {
+		return configFile{}, 0, err
+	}
+	return cfg, version, nil
+}
+
+// validate checks every profile and section of the config, reporting all
+// problems at once.
+func (cfg configFile) validate() error {
+	var errs []error
+	for _, name := range cfg.profileNames() {
+		opts, err := cfg.profileOptions(name)
+		if err == nil {
+			err = validatePatterns(opts)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", name, err))
+		}
+	}
+	if cfg.Hooks != nil {
+		if err := cfg.Hooks.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := compileSchedules(cfg, time.Local); err != nil {
+		errs = append(errs, err)
+	}
+	policy := confirmPolicy{Enabled: cfg.ConfirmBeforeQuit, NonInteractive: cfg.ConfirmNonInteractive}
+	if err := policy.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// patternWarnings returns the pattern warnings of every profile.
+func (cfg configFile) patternWarnings() []string {
+	var warnings []string
+	for _, name := range cfg.profileNames() {
+		opts, err := cfg.profileOptions(name)
+		if err != nil {
+			continue
+		}
+		entries := append(append([]string{}, opts.AllowedApps...), opts.DisallowedApps...)
+		for _, warning := range zencli.PatternWarnings(entries) {
+			warnings = append(warnings, fmt.Sprintf("profile %s: %s", name, warning))
+		}
+	}
+	return warnings
+}
+
+// validateConfigBytes parses raw, in the format of path, strictly and
+// validates the result, as done before a hand-edited config is saved.
+func validateConfigBytes(path string, raw []byte) error {
+	raw, err := configJSON(path, raw)
+	if err != nil {
+		return err
+	}
+	cfg, _, err := parseConfig(raw, true)
+	if err != nil {
+		return err
+	}
+	return cfg.validate()
+}
+
+func parseConfigArgs(args []string) (parsedArgs, error) {
+	if len(args) == 0 {
+		return parsedArgs{}, errors.New("zen config requires an action: path, show, edit, validate, reset, migrate or convert")
+	}
+
+	action := args[0]
+	rest := args[1:]
+	parsed := parsedArgs{command: commandConfig, action: action, actionArgs: rest}
+	switch action {
+	case configActionPath, configActionEdit, configActionReset, configActionMigrate:
+		if len(rest) != 0 {
+			return parsedArgs{}, fmt.Errorf("zen config %s does not accept extra arguments", action)
+		}
+	case configActionValidate:
+		if len(rest) > 1 {
+			return parsedArgs{}, errors.New("zen config validate accepts at most one path")
+		}
+	case configActionShow:
+		fs := flag.NewFlagSet("zen config show", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		effective := fs.Bool("effective", false, "show the merged allow-list instead of the file")
+		origin := fs.Bool("origin", false, "show which layer contributed each app")
+		profile := fs.String("profile", "", "named profile for --effective and --origin")
+		if err := fs.Parse(rest); err != nil {
+			return parsedArgs{}, err
+		}
+		if fs.NArg() != 0 {
+			return parsedArgs{}, errors.New("zen config show does not accept extra arguments")
+		}
+		if *effective && *origin {
+			return parsedArgs{}, errors.New("--effective and --origin cannot be used together")
+		}
+		if !*effective && !*origin && strings.TrimSpace(*profile) != "" {
+			return parsedArgs{}, errors.New("--profile requires --effective or --origin")
+		}
+		parsed.actionArgs = nil
+		parsed.effective = *effective
+		parsed.origin = *origin
+		parsed.profile = strings.TrimSpace(*profile)
+	case configActionConvert:
+		fs := flag.NewFlagSet("zen config convert", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		to := fs.String("to", "", "target format: json, yaml or toml")
+		if err := fs.Parse(rest); err != nil {
+			return parsedArgs{}, err
+		}
+		if fs.NArg() != 0 {
+			return parsedArgs{}, errors.New("zen config convert does not accept extra arguments")
+		}
+		if _, _, err := codecByName(*to); err != nil {
+			return parsedArgs{}, err
+		}
+		parsed.actionArgs = []string{*to}
+	default:
+		return parsedArgs{}, fmt.Errorf("unknown config action %q", action)
+	}
+
+	return parsed, nil
+}
+
+func runConfigCommand(out io.Writer, configPath string, parsed parsedArgs) error {
+	switch parsed.action {
+	case configActionPath:
+		fmt.Fprintln(out, configPath)
+		return nil
+	case configActionShow:
+		if parsed.effective || parsed.origin {
+			layers, err := loadConfigLayers(configPath, parsed.profile, false)
+			if err != nil {
+				return err
+			}
+			if parsed.origin {
+				return printOrigins(out, layerOrigins(layers))
+			}
+			return showEffectiveConfig(out, layers, parsed.profile)
+		}
+		return showConfigFile(out, configPath)
+	case configActionEdit:
+		return editConfigFile(out, configPath)
+	case configActionValidate:
+		path := configPath
+		if len(parsed.actionArgs) == 1 {
+			path = parsed.actionArgs[0]
+		}
+		return validateConfigFile(out, path)
+	case configActionReset:
+		return resetConfigFile(out, configPath, time.Now())
+	case configActionMigrate:
+		return migrateConfigFile(out, configPath)
+	case configActionConvert:
+		return convertConfigFile(out, configPath, parsed.actionArgs[0])
+	}
+	return fmt.Errorf("unknown config action %q", parsed.action)
+}
+
+func showConfigFile(out io.Writer, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(out, "zen-cli: no config file at %s; the built-in defaults apply.\n", path)
+			return nil
+		}
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	out.Write(raw)
+	return nil
+}
+
+// effectiveConfig is the allow-list a run with no flags would use.
+type effectiveConfig struct {
+	Layers                []string `json:"layers"`
+	Profile               string   `json:"profile"`
+	ReplaceDefaultAllowed bool     `json:"replaceDefaultAllowed"`
+	Allowed               []string `json:"allowed"`
+	Disallowed            []string `json:"disallowed"`
+}
+
+func showEffectiveConfig(out io.Writer, layers []optionLayer, profile string) error {
+	opts := mergeLayers(layers)
+	sources := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		sources = append(sources, layer.name+": "+layer.source)
+	}
+	return writeJSON(out, effectiveConfig{
+		Layers:                sources,
+		Profile:               reportProfile(profile),
+		ReplaceDefaultAllowed: opts.ReplaceDefaultAllowed,
+		Allowed:               nonNil(zencli.EffectiveAllowedApps(opts)),
+		Disallowed:            nonNil(opts.DisallowedApps),
+	})
+}
+
+func validateConfigFile(out io.Writer, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := validateConfigBytes(path, raw); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if converted, err := configJSON(path, raw); err == nil {
+		if cfg, _, err := parseConfig(converted, false); err == nil {
+			printPatternWarnings(out, cfg.patternWarnings())
+		}
+	}
+	fmt.Fprintf(out, "zen-cli config %s is valid.\n", path)
+	return nil
+}
+
+// editorCommand returns the user's editor and its arguments.
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// editConfigFile opens a copy of the config in the editor and saves it only
+// when it is still a valid config and nothing else changed the config in
+// the meantime. Rejected edits are kept in the copy so they are not lost.
+func editConfigFile(out io.Writer, path string) error {
+	existing, err := readExistingConfig(path)
+	if err != nil {
+		return err
+	}
+	original := existing
+	if existing == nil {
+		if original, err = marshalConfig(path, configFile{profileConfig: profileFromOptions(zencli.Options{})}); err != nil {
+			return err
+		}
+	}
+
+	draft, err := os.CreateTemp("", "zen-config-*"+filepath.Ext(path))
+	if err != nil {
+		return fmt.Errorf("failed to create draft config: %w", err)
+	}
+	_, err = draft.Write(original)
+	if closeErr := draft.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(draft.Name())
+		return fmt.Errorf("failed to write draft config %s: %w", draft.Name(), err)
+	}
+
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], draft.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed, your edits are in %s: %w", editor[0], draft.Name(), err)
+	}
+
+	edited, err := os.ReadFile(draft.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read draft config %s: %w", draft.Name(), err)
+	}
+	if bytes.Equal(edited, original) {
+		os.Remove(draft.Name())
+		fmt.Fprintln(out, "zen-cli config unchanged.")
+		return nil
+	}
+	if err := validateConfigBytes(path, edited); err != nil {
+		return fmt.Errorf("invalid config not saved, your edits are in %s: %w", draft.Name(), err)
+	}
+	err = withConfigLock(path, func() error {
+		current, err := readExistingConfig(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, existing) || (current == nil) != (existing == nil) {
+			return fmt.Errorf("config file %s changed while you were editing it, your edits are in %s", path, draft.Name())
+		}
+		return writeConfigBytes(path, edited)
+	})
+	if err != nil {
+		return err
+	}
+	os.Remove(draft.Name())
+	fmt.Fprintln(out, "zen-cli config updated.")
+	return nil
+}
+
+// resetConfigFile copies the config aside and writes the defaults over it,
+// both under one lock so no concurrent update lands in between.
+func resetConfigFile(out io.Writer, path string, now time.Time) error {
+	backup := fmt.Sprintf("%s.%s.bak", path, now.Format("20060102-150405"))
+	err := withConfigLock(path, func() error {
+		raw, err := readExistingConfig(path)
+		if err != nil {
+			return err
+		}
+		if raw == nil {
+			backup = ""
+		} else if err := os.WriteFile(backup, raw, 0o600); err != nil {
+			return fmt.Errorf("failed to back up config file %s: %w", path, err)
+		}
+		return writeConfigFile(path, configFile{profileConfig: profileFromOptions(zencli.Options{})})
+	})
+	if err != nil {
+		return err
+	}
+	if backup == "" {
+		fmt.Fprintln(out, "zen-cli config reset.")
+		return nil
+	}
+	fmt.Fprintf(out, "zen-cli config reset; the previous config is at %s.\n", backup)
+	return nil
+}
+
+// readExistingConfig returns the raw config, or nil when there is none yet.
+func readExistingConfig(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return raw, nil
+}
+
+// migrateConfigFile rewrites the config at the current version, keeping the
+// original next to it. Parsing is strict so that unknown keys, which the
+// rewrite would drop, are fixed first.
+func migrateConfigFile(out io.Writer, path string) error {
+	return withConfigLock(path, func() error {
+		return migrateLockedConfig(out, path)
+	})
+}
+
+func migrateLockedConfig(out io.Writer, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	converted, err := configJSON(path, raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	cfg, version, err := parseConfig(converted, true)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if version == currentConfigVersion {
+		fmt.Fprintf(out, "zen-cli config is already at version %d.\n", currentConfigVersion)
+		return nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := os.WriteFile(backup, raw, 0o600); err != nil {
+		return fmt.Errorf("failed to write config backup %s: %w", backup, err)
+	}
+	if err := writeConfigFile(path, cfg); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "zen-cli config migrated from version %d to %d; the original is at %s.\n", version, currentConfigVersion, backup)
+	return nil
+}
+
+// convertConfigFile writes the config in another format next to it, keeping
+// every key and the order they were written in. The original is renamed to
+// a .bak copy so it no longer takes precedence over the converted file.
+func convertConfigFile(out io.Writer, path string, format string) error {
+	codec, ext, err := codecByName(format)
+	if err != nil {
+		return err
+	}
+	if codecFor(path).name() == codec.name() {
+		return fmt.Errorf("config file %s is already %s", path, codec.name())
+	}
+	return withConfigLock(path, func() error {
+		return convertLockedConfig(out, path, codec, ext)
+	})
+}
+
+func convertLockedConfig(out io.Writer, path string, codec configCodec, ext string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	tree, err := codecFor(path).decode(raw)
+	if err == nil {
+		// Refuse to convert a config this build would not load.
+		var converted []byte
+		if converted, err = json.Marshal(tree); err == nil {
+			_, _, err = parseConfig(converted, strictConfigFromEnv())
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	body, err := codec.encode(tree)
+	if err != nil {
+		return fmt.Errorf("failed to convert config file %s: %w", path, err)
+	}
+
+	target := strings.TrimSuffix(path, filepath.Ext(path)) + ext
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("config file %s already exists", target)
+	}
+	if err := writeConfigBytes(target, body); err != nil {
+		return err
+	}
+	backup := path + ".bak"
+	if err := os.Rename(path, backup); err != nil {
+		return fmt.Errorf("failed to back up config file %s: %w", path, err)
+	}
+	fmt.Fprintf(out, "zen-cli config converted to %s; the original is at %s.\n", target, backup)
+	return nil
+}
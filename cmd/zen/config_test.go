@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfigMigratesUnversioned(t *testing.T) {
+	cfg, version, err := parseConfig([]byte(`{"allowedApps": ["Notes"]}`), false)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if version != 0 {
+		t.Fatalf("unexpected source version: got %d want 0", version)
+	}
+	if cfg.Version != currentConfigVersion {
+		t.Fatalf("unexpected version: got %d want %d", cfg.Version, currentConfigVersion)
+	}
+	if !reflect.DeepEqual(cfg.AllowedApps, []string{"Notes"}) {
+		t.Fatalf("unexpected allowed apps: %v", cfg.AllowedApps)
+	}
+}
+
+func TestParseConfigStrictRejectsUnknownKeys(t *testing.T) {
+	raw := []byte(`{"version": 1, "alowedApps": ["Notes"]}`)
+
+	if _, _, err := parseConfig(raw, false); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	_, _, err := parseConfig(raw, true)
+	if err == nil || !strings.Contains(err.Error(), "alowedApps") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+
+	nested := []byte(`{"profiles": {"coding": {"allowedApp": ["Xcode"]}}}`)
+	if _, _, err := parseConfig(nested, true); err == nil {
+		t.Fatal("expected unknown profile key error")
+	}
+}
+
+func TestParseConfigVersionErrors(t *testing.T) {
+	for _, raw := range []string{`{"version": 99}`, `{"version": "one"}`, `{"version": -1}`} {
+		if _, _, err := parseConfig([]byte(raw), false); err == nil {
+			t.Fatalf("expected error for %s", raw)
+		}
+	}
+}
+
+func TestReadConfigFileStrictFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"alowedApps": ["Notes"]}`), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	t.Setenv(strictConfigEnv, "1")
+	if _, err := readConfigFile(path, true); err == nil {
+		t.Fatal("expected unknown key error")
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	original := `{"allowedApps": ["Notes"], "disallowedApps": [], "replaceDefaultAllowed": false}`
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := migrateConfigFile(&out, path); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil {
+		t.Fatalf("expected backup, got %v", err)
+	}
+	if string(backup) != original {
+		t.Fatalf("unexpected backup: %s", backup)
+	}
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !strings.HasPrefix(string(migrated), "{\n  \"version\": 1,\n  \"allowedApps\": [\n    \"Notes\"\n  ]") {
+		t.Fatalf("unexpected migrated config:\n%s", migrated)
+	}
+
+	out.Reset()
+	if err := migrateConfigFile(&out, path); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if out.String() != "zen-cli config is already at version 1.\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestMigrateConfigFileRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"alowedApps": ["Notes"]}`), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := migrateConfigFile(&bytes.Buffer{}, path); err == nil {
+		t.Fatal("expected unknown key error")
+	}
+	if _, err := os.Stat(path + ".v0.bak"); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup, got %v", err)
+	}
+}
+
+func TestParseConfigArgs(t *testing.T) {
+	got, err := parseConfigArgs([]string{"migrate"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := parsedArgs{command: commandConfig, action: configActionMigrate, actionArgs: []string{}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args: got %+v want %+v", got, want)
+	}
+
+	got, err = parseConfigArgs([]string{"show", "--effective", "--profile", "coding"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want = parsedArgs{command: commandConfig, action: configActionShow, effective: true, profile: "coding"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args: got %+v want %+v", got, want)
+	}
+
+	got, err = parseConfigArgs([]string{"convert", "--to", "toml"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want = parsedArgs{command: commandConfig, action: configActionConvert, actionArgs: []string{"toml"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args: got %+v want %+v", got, want)
+	}
+
+	invalid := [][]string{nil, {"upgrade"}, {"migrate", "now"}, {"convert"}, {"convert", "--to", "ini"}, {"path", "x"}, {"validate", "a", "b"}, {"show", "--profile", "coding"}, {"show", "--effective", "--origin"}}
+	for _, args := range invalid {
+		if _, err := parseConfigArgs(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+	var out bytes.Buffer
+	if err := validateConfigFile(&out, path); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if out.String() != "zen-cli config "+path+" is valid.\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+
+	invalid := writeTestConfig(t, `{"profiles": {"coding": {"allowedApps": ["re:(x"]}}, "confirmNonInteractive": "ask"}`)
+	err := validateConfigFile(&bytes.Buffer{}, invalid)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, part := range []string{"profile coding", "confirmNonInteractive"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Fatalf("expected %q in error, got %v", part, err)
+		}
+	}
+}
+
+func TestValidateConfigFileWarnsAboutMalformedGlobs(t *testing.T) {
+	path := writeTestConfig(t, `{"profiles": {"beta": {"allowedApps": ["Foo [Beta"]}}}`)
+	var out bytes.Buffer
+	if err := validateConfigFile(&out, path); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := `zen-cli: warning: profile beta: pattern "Foo [Beta" is not a valid glob and only matches that exact name; write "name:Foo [Beta" to make that explicit` + "\n" +
+		"zen-cli config " + path + " is valid.\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output:\ngot  %q\nwant %q", out.String(), want)
+	}
+}
+
+func TestShowConfigFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	var out bytes.Buffer
+	if err := showConfigFile(&out, path); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "no config file at "+path) {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestShowEffectiveConfig(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+	layers, err := loadFileLayers([]optionLayer{{name: layerUser, source: path}}, "writing", true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := showEffectiveConfig(&out, layers, "writing"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := `{
+  "layers": [
+    "user: ` + path + `"
+  ],
+  "profile": "writing",
+  "replaceDefaultAllowed": true,
+  "allowed": [
+    "Obsidian"
+  ],
+  "disallowed": []
+}
+`
+	if out.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestResetConfigFile(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	if err := resetConfigFile(&out, path, now); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".20260302-090000.bak")
+	if err != nil {
+		t.Fatalf("expected backup, got %v", err)
+	}
+	if string(backup) != profileTestConfig {
+		t.Fatalf("unexpected backup: %s", backup)
+	}
+	cfg, err := readConfigFile(path, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(cfg.AllowedApps) != 0 || len(cfg.Profiles) != 0 {
+		t.Fatalf("expected default config, got %+v", cfg)
+	}
+}
+
+// useEditor points $EDITOR at a script that replaces the edited file with
+// body.
+func useEditor(t *testing.T, body string) {
+	t.Helper()
+	dir := t.TempDir()
+	content := filepath.Join(dir, "content.json")
+	if err := os.WriteFile(content, []byte(body), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	script := filepath.Join(dir, "editor.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncp '"+content+"' \"$1\"\n"), 0o700); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", script)
+}
+
+func TestEditConfigFileSavesValidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	useEditor(t, `{"version": 1, "allowedApps": ["Notes"]}`)
+
+	var out bytes.Buffer
+	if err := editConfigFile(&out, path); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if string(saved) != `{"version": 1, "allowedApps": ["Notes"]}` {
+		t.Fatalf("unexpected config: %s", saved)
+	}
+}
+
+func TestEditConfigFileRejectsInvalidConfig(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+	useEditor(t, `{"allowedApps": ["Notes"`)
+
+	err := editConfigFile(&bytes.Buffer{}, path)
+	if err == nil || !strings.Contains(err.Error(), "invalid config not saved") {
+		t.Fatalf("expected invalid config error, got %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if string(saved) != profileTestConfig {
+		t.Fatalf("expected config to be untouched, got %s", saved)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	if got := editorCommand(); !reflect.DeepEqual(got, []string{"code", "--wait"}) {
+		t.Fatalf("unexpected editor: %v", got)
+	}
+	t.Setenv("EDITOR", "")
+	if got := editorCommand(); !reflect.DeepEqual(got, []string{"vi"}) {
+		t.Fatalf("unexpected editor: %v", got)
+	}
+}
+
+func TestConvertConfigFile(t *testing.T) {
+	path := writeTestConfig(t, `{"version": 1, "allowedApps": ["Slack"], "custom": {"kept": true}}`)
+	var out bytes.Buffer
+	if err := convertConfigFile(&out, path, "yaml"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	target := strings.TrimSuffix(path, ".json") + ".yaml"
+	raw, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := "version: 1\nallowedApps:\n  - Slack\ncustom:\n  kept: true\n"
+	if string(raw) != want {
+		t.Fatalf("unexpected yaml: got %q want %q", raw, want)
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Fatalf("expected backup, got %v", err)
+	}
+	if got := findConfigFile(filepath.Dir(path), "config"); got != target {
+		t.Fatalf("unexpected config path after convert: got %q want %q", got, target)
+	}
+	if !strings.Contains(out.String(), target) {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+
+	if err := convertConfigFile(&bytes.Buffer{}, target, "yml"); err == nil {
+		t.Fatal("expected error converting to the same format")
+	}
+}
+
+func TestConvertConfigFileRefusesExistingTarget(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Slack"]}`)
+	target := strings.TrimSuffix(path, ".json") + ".toml"
+	if err := os.WriteFile(target, []byte("allowedApps = []\n"), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := convertConfigFile(&bytes.Buffer{}, path, "toml"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected existing target error, got %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected original to be kept, got %v", err)
+	}
+}
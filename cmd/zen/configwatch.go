@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+// defaultConfigPollInterval is how often long-running commands look for
+// config changes.
+const defaultConfigPollInterval = 2 * time.Second
+
+// configReload reports a config change. Err is set when the changed config
+// was rejected; Options then still holds the previous good config.
+type configReload struct {
+	Time    time.Time
+	Options zencli.Options
+	Err     error
+}
+
+// fileStamp identifies the content of a config file. The hash is only
+// recomputed when the modification time or size changes, and a change that
+// keeps the hash (such as a touch) is not a reload.
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+// configWatcher polls the config files of every layer and reloads the
+// options when one of them changes. Polling works on every platform without
+// file notification APIs.
+type configWatcher struct {
+	files    func() []string
+	load     func() (zencli.Options, error)
+	interval time.Duration
+	clock    zencli.Clock
+	log      io.Writer
+
+	mu          sync.Mutex
+	current     zencli.Options
+	stamps      map[string]fileStamp
+	subscribers []chan configReload
+}
+
+// newConfigWatcher loads the layered options of profile, which must be
+// valid, and returns a watcher that keeps them up to date once Run is
+// called. Rejected changes are logged to log.
+func newConfigWatcher(configPath string, profile string, log io.Writer) (*configWatcher, error) {
+	w := &configWatcher{
+		files: func() []string {
+			var paths []string
+			for _, layer := range configLayerFiles(configPath) {
+				paths = append(paths, layer.source)
+			}
+			return paths
+		},
+		load: func() (zencli.Options, error) {
+			opts, err := loadLayeredOptions(configPath, profile, false)
+			if err != nil {
+				return zencli.Options{}, err
+			}
+			return opts, validateOptions(opts)
+		},
+		interval: defaultConfigPollInterval,
+		clock:    zencli.SystemClock,
+		log:      log,
+	}
+	return w, w.init()
+}
+
+func (w *configWatcher) init() error {
+	w.stamps = stampFiles(w.files(), nil)
+	opts, err := w.load()
+	if err != nil {
+		return err
+	}
+	w.current = opts
+	return nil
+}
+
+// Options returns the latest good options.
+func (w *configWatcher) Options() zencli.Options {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Subscribe returns a channel receiving every reload until Run returns,
+// which closes it. A subscriber that falls behind only misses older
+// reloads; the latest one is always delivered.
+func (w *configWatcher) Subscribe() <-chan configReload {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch := make(chan configReload, 1)
+	w.subscribers = append(w.subscribers, ch)
+	return ch
+}
+
+// Run checks the config every interval until ctx is cancelled.
+func (w *configWatcher) Run(ctx context.Context) {
+	defer w.closeSubscribers()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.clock.After(w.interval):
+			w.check()
+		}
+	}
+}
+
+// check reloads the config when one of its files changed since the last
+// check and reports whether it did.
+func (w *configWatcher) check() (configReload, bool) {
+	stamps := stampFiles(w.files(), w.stamps)
+	if sameStamps(stamps, w.stamps) {
+		return configReload{}, false
+	}
+	w.stamps = stamps
+
+	opts, err := w.load()
+	reload := configReload{Time: w.clock.Now(), Options: opts, Err: err}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		reload.Options = w.current
+		fmt.Fprintf(w.log, "zen-cli: config change ignored, keeping the previous config: %v\n", err)
+	} else {
+		w.current = opts
+		fmt.Fprintln(w.log, "zen-cli: config reloaded.")
+	}
+	for _, ch := range w.subscribers {
+		publishReload(ch, reload)
+	}
+	return reload, true
+}
+
+// publishReload replaces an undelivered reload with the newer one rather
+// than blocking the watcher on a slow subscriber.
+func publishReload(ch chan configReload, reload configReload) {
+	select {
+	case ch <- reload:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- reload
+}
+
+func (w *configWatcher) closeSubscribers() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range w.subscribers {
+		close(ch)
+	}
+	w.subscribers = nil
+}
+
+// stampFiles stamps paths, reusing the hashes in previous for files whose
+// modification time and size did not change.
+func stampFiles(paths []string, previous map[string]fileStamp) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps[path] = fileStamp{}
+			continue
+		}
+		stamp := fileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+		if old, ok := previous[path]; ok && old.exists && old.modTime.Equal(stamp.modTime) && old.size == stamp.size {
+			stamp.hash = old.hash
+		} else if raw, err := os.ReadFile(path); err == nil {
+			stamp.hash = sha256.Sum256(raw)
+		}
+		stamps[path] = stamp
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		other, ok := b[path]
+		if !ok || stamp.exists != other.exists || !bytes.Equal(stamp.hash[:], other.hash[:]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tickClock fires After only when the test sends a tick.
+type tickClock struct {
+	ticks chan time.Time
+}
+
+func (c tickClock) Now() time.Time {
+	return time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+}
+
+func (c tickClock) After(time.Duration) <-chan time.Time {
+	return c.ticks
+}
+
+func newTestConfigWatcher(t *testing.T, path string, log *bytes.Buffer) *configWatcher {
+	t.Helper()
+	t.Setenv(configDirsEnv, t.TempDir())
+	watcher, err := newConfigWatcher(path, "", log)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	watcher.clock = tickClock{ticks: make(chan time.Time)}
+	return watcher
+}
+
+func rewriteConfig(t *testing.T, path string, body string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestConfigWatcherReloadsChanges(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Slack"]}`)
+	var log bytes.Buffer
+	watcher := newTestConfigWatcher(t, path, &log)
+	if got := watcher.Options().AllowedApps; !reflect.DeepEqual(got, []string{"Slack"}) {
+		t.Fatalf("unexpected initial apps: %v", got)
+	}
+	reloads := watcher.Subscribe()
+
+	if _, ok := watcher.check(); ok {
+		t.Fatal("expected no reload without a change")
+	}
+
+	later := time.Now().Add(time.Minute)
+	rewriteConfig(t, path, `{"allowedApps": ["Slack", "Notes"]}`, later)
+	reload, ok := watcher.check()
+	if !ok || reload.Err != nil {
+		t.Fatalf("expected a reload, got %+v %v", reload, ok)
+	}
+	want := []string{"Slack", "Notes"}
+	if got := watcher.Options().AllowedApps; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected apps: got %v want %v", got, want)
+	}
+	if got := (<-reloads).Options.AllowedApps; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected published apps: got %v want %v", got, want)
+	}
+
+	// A touch changes the modification time but not the content.
+	rewriteConfig(t, path, `{"allowedApps": ["Slack", "Notes"]}`, later.Add(time.Minute))
+	if _, ok := watcher.check(); ok {
+		t.Fatal("expected no reload when only the modification time changed")
+	}
+	if log.String() != "zen-cli: config reloaded.\n" {
+		t.Fatalf("unexpected log: %q", log.String())
+	}
+}
+
+func TestConfigWatcherKeepsPreviousConfigOnError(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Slack"]}`)
+	var log bytes.Buffer
+	watcher := newTestConfigWatcher(t, path, &log)
+
+	rewriteConfig(t, path, `{"allowedApps": ["re:(x"]}`, time.Now().Add(time.Minute))
+	reload, ok := watcher.check()
+	if !ok || reload.Err == nil {
+		t.Fatalf("expected a rejected reload, got %+v %v", reload, ok)
+	}
+	if !reflect.DeepEqual(reload.Options.AllowedApps, []string{"Slack"}) {
+		t.Fatalf("expected the previous options, got %v", reload.Options.AllowedApps)
+	}
+	if got := watcher.Options().AllowedApps; !reflect.DeepEqual(got, []string{"Slack"}) {
+		t.Fatalf("expected the previous options to stay, got %v", got)
+	}
+	if !strings.Contains(log.String(), "config change ignored") {
+		t.Fatalf("expected the rejection to be logged, got %q", log.String())
+	}
+
+	// The same broken file is not reported again.
+	if _, ok := watcher.check(); ok {
+		t.Fatal("expected no reload without a change")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	reload, ok = watcher.check()
+	if !ok || reload.Err != nil || len(reload.Options.AllowedApps) != 0 {
+		t.Fatalf("expected a removed config to reload as empty, got %+v %v", reload, ok)
+	}
+}
+
+func TestConfigWatcherRunClosesSubscribers(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Slack"]}`)
+	watcher := newTestConfigWatcher(t, path, &bytes.Buffer{})
+	ticks := watcher.clock.(tickClock).ticks
+	reloads := watcher.Subscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	rewriteConfig(t, path, `{"allowedApps": ["Notes"]}`, time.Now().Add(time.Minute))
+	ticks <- time.Now()
+	if got := (<-reloads).Options.AllowedApps; !reflect.DeepEqual(got, []string{"Notes"}) {
+		t.Fatalf("unexpected published apps: %v", got)
+	}
+
+	cancel()
+	<-done
+	if _, ok := <-reloads; ok {
+		t.Fatal("expected the subscription to be closed")
+	}
+}
+
+func TestPublishReloadKeepsLatest(t *testing.T) {
+	ch := make(chan configReload, 1)
+	first := configReload{Time: time.Unix(1, 0)}
+	second := configReload{Time: time.Unix(2, 0)}
+	publishReload(ch, first)
+	publishReload(ch, second)
+	if got := <-ch; !got.Time.Equal(second.Time) {
+		t.Fatalf("unexpected reload: got %v want %v", got.Time, second.Time)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"zen-cli/internal/zencli"
+)
+
+const (
+	nonInteractiveProceed = "proceed"
+	nonInteractiveAbort   = "abort"
+)
+
+var errQuitDeclined = errors.New("no apps were closed")
+
+// confirmPolicy is the confirmBeforeQuit setting of the config. When stdin
+// is not a terminal nobody can answer, so NonInteractive decides instead:
+// "proceed" (the default, so scripts keep working) or "abort".
+type confirmPolicy struct {
+	Enabled        bool
+	NonInteractive string
+}
+
+func (p confirmPolicy) validate() error {
+	switch p.NonInteractive {
+	case "", nonInteractiveProceed, nonInteractiveAbort:
+		return nil
+	}
+	return fmt.Errorf("invalid confirmNonInteractive %q: expected proceed or abort", p.NonInteractive)
+}
+
+func loadConfirmPolicy(path string, required bool) (confirmPolicy, error) {
+	if strings.TrimSpace(path) == "" {
+		return confirmPolicy{}, nil
+	}
+	cfg, err := readConfigFile(path, required)
+	if err != nil {
+		return confirmPolicy{}, err
+	}
+	policy := confirmPolicy{Enabled: cfg.ConfirmBeforeQuit, NonInteractive: cfg.ConfirmNonInteractive}
+	if err := policy.validate(); err != nil {
+		return confirmPolicy{}, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// confirmQuit asks before targets are quit. It returns nil when the run
+// may go ahead.
+func confirmQuit(in *bufio.Reader, out io.Writer, terminal bool, policy confirmPolicy, targets []zencli.AppInfo) error {
+	if !policy.Enabled || len(targets) == 0 {
+		return nil
+	}
+	if !terminal {
+		if policy.NonInteractive == nonInteractiveAbort {
+			return errors.New("confirmBeforeQuit is set and stdin is not a terminal; pass --yes to quit apps")
+		}
+		return nil
+	}
+
+	fmt.Fprintln(out, "zen-cli will quit:")
+	for _, app := range targets {
+		fmt.Fprintf(out, "- %s\n", app.Name)
+	}
+	if !confirm(in, out, "Quit these apps?") {
+		return errQuitDeclined
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfirmQuitAsksOnTerminal(t *testing.T) {
+	policy := confirmPolicy{Enabled: true}
+	targets := appInfoList("Safari", "Slack")
+
+	var out bytes.Buffer
+	if err := confirmQuit(bufio.NewReader(strings.NewReader("y\n")), &out, true, policy, targets); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := "zen-cli will quit:\n- Safari\n- Slack\nQuit these apps? [y/N] "
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+
+	err := confirmQuit(bufio.NewReader(strings.NewReader("n\n")), &bytes.Buffer{}, true, policy, targets)
+	if !errors.Is(err, errQuitDeclined) {
+		t.Fatalf("unexpected error: got %v want %v", err, errQuitDeclined)
+	}
+}
+
+func TestConfirmQuitNonInteractive(t *testing.T) {
+	targets := appInfoList("Slack")
+	in := bufio.NewReader(strings.NewReader(""))
+
+	var out bytes.Buffer
+	if err := confirmQuit(in, &out, false, confirmPolicy{Enabled: true}, targets); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no prompt, got %q", out.String())
+	}
+
+	policy := confirmPolicy{Enabled: true, NonInteractive: nonInteractiveAbort}
+	if err := confirmQuit(in, &out, false, policy, targets); err == nil {
+		t.Fatal("expected abort error, got nil")
+	}
+}
+
+func TestConfirmQuitSkipped(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader(""))
+	if err := confirmQuit(in, &bytes.Buffer{}, true, confirmPolicy{}, appInfoList("Slack")); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := confirmQuit(in, &bytes.Buffer{}, true, confirmPolicy{Enabled: true}, nil); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestLoadConfirmPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	raw := `{"confirmBeforeQuit": true, "confirmNonInteractive": "abort"}`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	got, err := loadConfirmPolicy(path, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := confirmPolicy{Enabled: true, NonInteractive: nonInteractiveAbort}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected policy: got %+v want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"confirmNonInteractive": "ask"}`), 0o644); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := loadConfirmPolicy(path, true); err == nil {
+		t.Fatal("expected invalid policy error")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package main
+
+import "os/exec"
+
+// zen-cli only runs on macOS and Linux; elsewhere the process is started
+// normally.
+func detachProcess(*exec.Cmd) {}
@@ -0,0 +1,14 @@
+//go:build darwin || linux
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts cmd in a new session so it keeps running, and gets no
+// SIGHUP, when the terminal that started it closes.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
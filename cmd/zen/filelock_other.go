@@ -0,0 +1,11 @@
+//go:build !darwin && !linux
+
+package main
+
+import "os"
+
+// zen-cli only runs on macOS and Linux; elsewhere config updates are not
+// locked.
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
@@ -0,0 +1,297 @@
+//go:build darwin || linux
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+func TestConcurrentAddsKeepEveryApp(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Ghostty"]}`)
+
+	const adds = 40
+	var wg sync.WaitGroup
+	errs := make(chan error, adds)
+	for i := 0; i < adds; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- updateConfigFile(path, func(cfg *configFile) error {
+				opts, err := cfg.profileOptions(defaultProfileName)
+				if err != nil {
+					return err
+				}
+				return cfg.setProfileOptions(defaultProfileName, addAllowedApps(opts, []string{fmt.Sprintf("App %02d", i)}))
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	opts, err := loadProfileFromConfig(path, defaultProfileName, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := []string{"Ghostty"}
+	for i := 0; i < adds; i++ {
+		want = append(want, fmt.Sprintf("App %02d", i))
+	}
+	got := append([]string{}, opts.AllowedApps...)
+	sort.Strings(got[1:])
+	if len(got) != len(want) || fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("unexpected allowed apps: got %v want %v", got, want)
+	}
+}
+
+func TestWriteConfigBytesKeepsPermissions(t *testing.T) {
+	path := writeTestConfig(t, `{}`)
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := writeConfigBytes(path, []byte(`{"allowedApps": ["Notes"]}`)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Fatalf("unexpected mode: got %v want %v", info.Mode().Perm(), os.FileMode(0o640))
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the config to remain, got %v", entries)
+	}
+}
+
+func TestWriteConfigBytesNewFileIsPrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zen-cli", "config.json")
+	if err := writeConfigBytes(path, []byte(`{}`)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("unexpected mode: got %v want %v", info.Mode().Perm(), os.FileMode(0o600))
+	}
+}
+
+func TestWriteConfigBytesFollowsSymlink(t *testing.T) {
+	target := writeTestConfig(t, `{}`)
+	link := filepath.Join(t.TempDir(), "config.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	err := updateConfigFile(link, func(cfg *configFile) error {
+		return cfg.setProfileOptions(defaultProfileName, zencli.Options{AllowedApps: []string{"Notes"}})
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("expected the config to stay a symlink")
+	}
+	opts, err := loadProfileFromConfig(target, defaultProfileName, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if fmt.Sprint(opts.AllowedApps) != "[Notes]" {
+		t.Fatalf("unexpected allowed apps: %v", opts.AllowedApps)
+	}
+}
+
+// runWhileLocked starts run while another process holds the config lock,
+// which rewrites the config to body before releasing it. run must wait for
+// the lock and so see body.
+func runWhileLocked(t *testing.T, path string, body string, run func() error) error {
+	t.Helper()
+	unlock, err := lockConfigFile(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- run() }()
+
+	select {
+	case err := <-done:
+		unlock()
+		t.Fatalf("expected to wait for the config lock, returned %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	unlock()
+	return <-done
+}
+
+func TestMigrateConfigFileWaitsForLock(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Notes"]}`)
+
+	err := runWhileLocked(t, path, `{"allowedApps": ["Notes", "Mail"]}`, func() error {
+		return migrateConfigFile(io.Discard, path)
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	opts, err := loadProfileFromConfig(path, defaultProfileName, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if fmt.Sprint(opts.AllowedApps) != "[Notes Mail]" {
+		t.Fatalf("unexpected allowed apps: %v", opts.AllowedApps)
+	}
+}
+
+func TestResetConfigFileWaitsForLock(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Notes"]}`)
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	changed := `{"allowedApps": ["Notes", "Mail"]}`
+	err := runWhileLocked(t, path, changed, func() error {
+		return resetConfigFile(io.Discard, path, now)
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	backup, err := os.ReadFile(path + ".20260302-090000.bak")
+	if err != nil {
+		t.Fatalf("expected backup, got %v", err)
+	}
+	if string(backup) != changed {
+		t.Fatalf("unexpected backup: got %s want %s", backup, changed)
+	}
+}
+
+func TestEditConfigFileRefusesConcurrentChange(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Notes"]}`)
+	useEditor(t, `{"version": 1, "allowedApps": ["Slack"]}`)
+	t.Setenv("TMPDIR", t.TempDir())
+
+	changed := `{"allowedApps": ["Notes", "Mail"]}`
+	err := runWhileLocked(t, path, changed, func() error {
+		return editConfigFile(io.Discard, path)
+	})
+	if err == nil || !strings.Contains(err.Error(), "changed while you were editing it") {
+		t.Fatalf("expected concurrent change error, got %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if string(saved) != changed {
+		t.Fatalf("expected the concurrent change to be kept, got %s", saved)
+	}
+}
+
+func TestConvertConfigFileWaitsForLock(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Notes"]}`)
+
+	err := runWhileLocked(t, path, `{"allowedApps": ["Notes", "Mail"]}`, func() error {
+		return convertConfigFile(io.Discard, path, "yaml")
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	opts, err := loadProfileFromConfig(filepath.Join(filepath.Dir(path), "config.yaml"), defaultProfileName, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if fmt.Sprint(opts.AllowedApps) != "[Notes Mail]" {
+		t.Fatalf("unexpected allowed apps: %v", opts.AllowedApps)
+	}
+}
+
+func TestConcurrentSnapshotsGetDistinctIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "snapshots.json")
+
+	const runs = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, runs)
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := appendSnapshot(path, snapshot{Command: historyRun, Apps: []snapshotApp{{App: fmt.Sprintf("App %02d", i)}}}, runs)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	snapshots, err := readSnapshots(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(snapshots) != runs {
+		t.Fatalf("unexpected snapshot count: got %d want %d", len(snapshots), runs)
+	}
+	for i, snap := range snapshots {
+		if snap.ID != i+1 {
+			t.Fatalf("unexpected id at %d: got %d want %d", i, snap.ID, i+1)
+		}
+	}
+}
+
+func TestAppendHistoryWaitsForLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	first := historyEntry{Time: start, Command: historyRun, Profile: "default", Targets: []string{}}
+	line, err := json.Marshal(first)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	// The entry is about 80 bytes, so a 100 byte limit rotates the line
+	// written while the lock was held.
+	second := historyEntry{Time: start.Add(time.Minute), Command: historyRun, Profile: "default", Targets: []string{}}
+	err = runWhileLocked(t, path, string(line)+"\n", func() error {
+		return appendHistory(path, second, 100)
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	got, err := readHistory(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(got) != 2 || !got[0].Time.Equal(first.Time) || !got[1].Time.Equal(second.Time) {
+		t.Fatalf("unexpected entries: %+v", got)
+	}
+	if _, err := os.Stat(historyBackupPath(path, 1)); err != nil {
+		t.Fatalf("expected the locked write to be rotated, got %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+const (
+	historyRun          = "run"
+	historyDryRun       = "dry-run"
+	historySessionStart = "session-start"
+	historySessionEnd   = "session-end"
+	historyWatch        = "watch"
+	historySchedule     = "schedule"
+)
+
+const (
+	// maxHistorySize is the size at which history.jsonl is rotated.
+	maxHistorySize = 1 << 20
+	// historyBackups is how many rotated files are kept, as history.jsonl.1
+	// (newest) to history.jsonl.N (oldest).
+	historyBackups = 3
+)
+
+// historyEntry is one line of history.jsonl.
+type historyEntry struct {
+	Time            time.Time   `json:"time"`
+	Command         string      `json:"command"`
+	Profile         string      `json:"profile"`
+	AllowHash       string      `json:"allowHash,omitempty"`
+	Targets         []string    `json:"targets"`
+	Results         []appResult `json:"results,omitempty"`
+	Relaunched      []string    `json:"relaunched,omitempty"`
+	DurationSeconds int64       `json:"durationSeconds,omitempty"`
+}
+
+func (e historyEntry) involves(app string) bool {
+	for _, target := range e.Targets {
+		if strings.EqualFold(target, app) {
+			return true
+		}
+	}
+	for _, result := range e.Results {
+		if strings.EqualFold(result.App, app) {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultHistoryPath() (string, error) {
+	if xdg := strings.TrimSpace(os.Getenv("XDG_STATE_HOME")); xdg != "" {
+		return filepath.Join(xdg, "zen-cli", "history.jsonl"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "zen-cli", "history.jsonl"), nil
+}
+
+// allowListHash identifies an effective allow-list independent of order and
+// case, so history entries can be grouped by the rules that produced them.
+func allowListHash(allowed []string) string {
+	keys := make([]string, 0, len(allowed))
+	for _, app := range allowed {
+		keys = append(keys, strings.ToLower(strings.TrimSpace(app)))
+	}
+	sort.Strings(keys)
+	sum := sha256.Sum256([]byte(strings.Join(keys, "\n")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func newRunHistoryEntry(command string, profile string, allowed []string, results []zencli.QuitResult) historyEntry {
+	entry := historyEntry{
+		Time:      time.Now(),
+		Command:   command,
+		Profile:   reportProfile(profile),
+		AllowHash: allowListHash(allowed),
+		Targets:   make([]string, 0, len(results)),
+		Results:   make([]appResult, 0, len(results)),
+	}
+	for _, result := range results {
+		entry.Targets = append(entry.Targets, result.App)
+		entry.Results = append(entry.Results, newAppResult(result))
+	}
+	return entry
+}
+
+// recordHistory appends entry to the history log. History is best effort:
+// failures are reported on stderr and never fail the command.
+func recordHistory(entry historyEntry) {
+	path, err := defaultHistoryPath()
+	if err == nil {
+		err = appendHistory(path, entry, maxHistorySize)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "zen-cli: failed to record history: %v\n", err)
+	}
+}
+
+// appendHistory adds entry to the log, rotating it first when the entry
+// would push it past maxSize. The size check, rotation and append run under
+// the lock on history.jsonl.lock, so concurrent zen processes neither rotate
+// twice nor append to a file another one is renaming.
+func appendHistory(path string, entry historyEntry, maxSize int64) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory %s: %w", dir, err)
+	}
+	return withConfigLock(path, func() error {
+		return writeHistoryLine(path, line, maxSize)
+	})
+}
+
+func writeHistoryLine(path string, line []byte, maxSize int64) error {
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > maxSize {
+		if err := rotateHistory(path); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file %s: %w", path, err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write history file %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write history file %s: %w", path, err)
+	}
+	return nil
+}
+
+func rotateHistory(path string) error {
+	for i := historyBackups - 1; i >= 1; i-- {
+		from, to := historyBackupPath(path, i), historyBackupPath(path, i+1)
+		if err := os.Rename(from, to); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate history file %s: %w", from, err)
+		}
+	}
+	if err := os.Rename(path, historyBackupPath(path, 1)); err != nil {
+		return fmt.Errorf("failed to rotate history file %s: %w", path, err)
+	}
+	return nil
+}
+
+func historyBackupPath(path string, n int) string {
+	return path + "." + strconv.Itoa(n)
+}
+
+// readHistory returns every entry of the log and its rotated files, oldest
+// first. Lines that do not parse, such as one cut short by a crash, are
+// skipped.
+func readHistory(path string) ([]historyEntry, error) {
+	files := make([]string, 0, historyBackups+1)
+	for i := historyBackups; i >= 1; i-- {
+		files = append(files, historyBackupPath(path, i))
+	}
+	files = append(files, path)
+
+	var entries []historyEntry
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read history file %s: %w", name, err)
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), maxHistorySize)
+		for scanner.Scan() {
+			var entry historyEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history file %s: %w", name, err)
+		}
+	}
+	return entries, nil
+}
+
+func filterHistory(entries []historyEntry, since time.Time, app string) []historyEntry {
+	filtered := make([]historyEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !since.IsZero() && entry.Time.Before(since) {
+			continue
+		}
+		if app != "" && !entry.involves(app) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+// parseSince parses a look-back window such as 7d, 2w or 36h.
+func parseSince(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(raw, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid --since %q: expected a duration such as 7d or 12h", raw)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --since %q: expected a duration such as 7d or 12h", raw)
+	}
+	return d, nil
+}
+
+func parseHistoryArgs(args []string) (parsedArgs, error) {
+	fs := flag.NewFlagSet("zen history", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	since := fs.String("since", "", "only show entries newer than this, e.g. 7d or 12h")
+	app := fs.String("app", "", "only show entries involving this app")
+	output := fs.String("output", string(outputText), "output format: text, json or ndjson")
+	if err := fs.Parse(args); err != nil {
+		return parsedArgs{}, err
+	}
+	if fs.NArg() != 0 {
+		return parsedArgs{}, errors.New("zen history does not accept extra arguments")
+	}
+
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return parsedArgs{}, err
+	}
+	var window time.Duration
+	if strings.TrimSpace(*since) != "" {
+		if window, err = parseSince(*since); err != nil {
+			return parsedArgs{}, err
+		}
+	}
+
+	return parsedArgs{
+		command:      commandHistory,
+		output:       format,
+		historySince: window,
+		historyApp:   strings.TrimSpace(*app),
+	}, nil
+}
+
+func runHistoryCommand(out io.Writer, parsed parsedArgs) error {
+	path, err := defaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	entries, err := readHistory(path)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if parsed.historySince > 0 {
+		since = time.Now().Add(-parsed.historySince)
+	}
+	return printHistory(out, parsed.output, filterHistory(entries, since, parsed.historyApp))
+}
+
+type historyReport struct {
+	Command string         `json:"command"`
+	Entries []historyEntry `json:"entries"`
+}
+
+func printHistory(out io.Writer, format outputFormat, entries []historyEntry) error {
+	switch format {
+	case outputJSON:
+		if entries == nil {
+			entries = []historyEntry{}
+		}
+		return writeJSON(out, historyReport{Command: string(commandHistory), Entries: entries})
+	case outputNDJSON:
+		enc := json.NewEncoder(out)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "zen-cli: no history entries.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tCOMMAND\tPROFILE\tAPPS")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04"), entry.Command, entry.Profile, historyApps(entry))
+	}
+	return tw.Flush()
+}
+
+// historyApps summarizes the apps of an entry, with their outcome when the
+// entry has one.
+func historyApps(entry historyEntry) string {
+	if len(entry.Results) > 0 {
+		apps := make([]string, 0, len(entry.Results))
+		for _, result := range entry.Results {
+			apps = append(apps, fmt.Sprintf("%s (%s)", result.App, result.Status))
+		}
+		return strings.Join(apps, ", ")
+	}
+	if len(entry.Targets) == 0 {
+		return "-"
+	}
+	return strings.Join(entry.Targets, ", ")
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultHistoryPathUsesXDGStateHome(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/xdg-state")
+
+	got, err := defaultHistoryPath()
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := filepath.Join("/tmp/xdg-state", "zen-cli", "history.jsonl"); got != want {
+		t.Fatalf("unexpected path: got %q want %q", got, want)
+	}
+}
+
+func TestDefaultHistoryPathFallsBackToHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", home)
+
+	got, err := defaultHistoryPath()
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := filepath.Join(home, ".local", "state", "zen-cli", "history.jsonl"); got != want {
+		t.Fatalf("unexpected path: got %q want %q", got, want)
+	}
+}
+
+func TestAllowListHashIgnoresOrderAndCase(t *testing.T) {
+	a := allowListHash([]string{"Terminal", "Slack"})
+	b := allowListHash([]string{"slack", " terminal"})
+	if a != b {
+		t.Fatalf("expected equal hashes, got %q and %q", a, b)
+	}
+	if a == allowListHash([]string{"Terminal"}) {
+		t.Fatal("expected different allow-lists to hash differently")
+	}
+	if len(a) != 12 {
+		t.Fatalf("unexpected hash length: %q", a)
+	}
+}
+
+func TestAppendAndReadHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zen-cli", "history.jsonl")
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	first := historyEntry{Time: at, Command: historyDryRun, Profile: "default", AllowHash: "abc", Targets: []string{"Slack"}}
+	second := historyEntry{
+		Time:    at.Add(time.Hour),
+		Command: historyRun,
+		Profile: "coding",
+		Targets: []string{"Slack"},
+		Results: []appResult{{App: "Slack", Status: statusClosed, Graceful: "ok", Terminate: "skipped", ForceKill: "skipped"}},
+	}
+
+	for _, entry := range []historyEntry{first, second} {
+		if err := appendHistory(path, entry, maxHistorySize); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	got, err := readHistory(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, []historyEntry{first, second}) {
+		t.Fatalf("unexpected entries: got %+v", got)
+	}
+}
+
+func TestReadHistorySkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	body := `{"time":"2026-03-02T09:00:00Z","command":"run","profile":"default","targets":[]}
+{"time":"2026-03-02T10:00
+`
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("failed to write history: %v", err)
+	}
+
+	got, err := readHistory(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(got) != 1 || got[0].Command != historyRun {
+		t.Fatalf("unexpected entries: %+v", got)
+	}
+}
+
+func TestReadHistoryMissing(t *testing.T) {
+	got, err := readHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no entries, got %+v", got)
+	}
+}
+
+func TestAppendHistoryRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	// Each entry is about 80 bytes, so a 100 byte limit rotates on every
+	// append after the first.
+	for i := 0; i < 6; i++ {
+		entry := historyEntry{Time: start.Add(time.Duration(i) * time.Minute), Command: historyRun, Profile: "default", Targets: []string{}}
+		if err := appendHistory(path, entry, 100); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	if _, err := os.Stat(historyBackupPath(path, historyBackups+1)); !os.IsNotExist(err) {
+		t.Fatalf("expected at most %d backups, got err %v", historyBackups, err)
+	}
+
+	got, err := readHistory(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(got) != historyBackups+1 {
+		t.Fatalf("unexpected entry count: %d", len(got))
+	}
+	for i, entry := range got {
+		want := start.Add(time.Duration(i+2) * time.Minute)
+		if !entry.Time.Equal(want) {
+			t.Fatalf("entry %d: got %s want %s", i, entry.Time, want)
+		}
+	}
+}
+
+func TestFilterHistory(t *testing.T) {
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	entries := []historyEntry{
+		{Time: at, Command: historyRun, Targets: []string{"Slack"}},
+		{Time: at.Add(48 * time.Hour), Command: historyDryRun, Targets: []string{"Music"}},
+		{Time: at.Add(72 * time.Hour), Command: historyWatch, Results: []appResult{{App: "slack", Status: statusClosed}}},
+	}
+
+	got := filterHistory(entries, at.Add(24*time.Hour), "")
+	if len(got) != 2 || got[0].Command != historyDryRun {
+		t.Fatalf("unexpected --since result: %+v", got)
+	}
+
+	got = filterHistory(entries, time.Time{}, "Slack")
+	if len(got) != 2 || got[0].Command != historyRun || got[1].Command != historyWatch {
+		t.Fatalf("unexpected --app result: %+v", got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	cases := map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for raw, want := range cases {
+		got, err := parseSince(raw)
+		if err != nil {
+			t.Fatalf("parseSince(%q): unexpected error %v", raw, err)
+		}
+		if got != want {
+			t.Fatalf("parseSince(%q): got %s want %s", raw, got, want)
+		}
+	}
+
+	for _, raw := range []string{"d", "-1d", "0h", "yesterday"} {
+		if _, err := parseSince(raw); err == nil {
+			t.Fatalf("parseSince(%q): expected error, got nil", raw)
+		}
+	}
+}
+
+func TestOptionsFromArgsHistorySubcommand(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"history", "--since", "7d", "--app", "Slack", "--output", "json"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if parsed.command != commandHistory || parsed.historySince != 7*24*time.Hour || parsed.historyApp != "Slack" || parsed.output != outputJSON {
+		t.Fatalf("unexpected parsed args: %+v", parsed)
+	}
+
+	if _, err := optionsFromArgs([]string{"history", "extra"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestPrintHistoryText(t *testing.T) {
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	entries := []historyEntry{
+		{Time: at, Command: historyDryRun, Profile: "default", Targets: []string{"Slack", "Music"}},
+		{Time: at.Add(time.Minute), Command: historyRun, Profile: "coding", Results: []appResult{
+			{App: "Slack", Status: statusClosed},
+			{App: "Music", Status: statusFailed},
+		}},
+		{Time: at.Add(2 * time.Minute), Command: historySessionEnd, Profile: "default", Targets: []string{}},
+	}
+
+	var out bytes.Buffer
+	if err := printHistory(&out, outputText, entries); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "TIME              COMMAND      PROFILE  APPS\n" +
+		"2026-03-02 09:00  dry-run      default  Slack, Music\n" +
+		"2026-03-02 09:01  run          coding   Slack (closed), Music (failed)\n" +
+		"2026-03-02 09:02  session-end  default  -\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintHistoryJSONEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := printHistory(&out, outputJSON, nil); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "{\n  \"command\": \"history\",\n  \"entries\": []\n}\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
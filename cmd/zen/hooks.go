@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+const (
+	hookFailureAbort    = "abort"
+	hookFailureContinue = "continue"
+)
+
+// hooksConfig is the "hooks" section of the config. Commands run with sh -c.
+// A failing preRun hook aborts the run unless onPreRunFailure is
+// "continue"; postRun and perApp failures are only reported.
+type hooksConfig struct {
+	PreRun          []hookCommand `json:"preRun,omitempty"`
+	PostRun         []hookCommand `json:"postRun,omitempty"`
+	PerApp          []hookCommand `json:"perApp,omitempty"`
+	Timeout         string        `json:"timeout,omitempty"`
+	OnPreRunFailure string        `json:"onPreRunFailure,omitempty"`
+}
+
+// hookCommand is written either as a plain command string or as an object
+// with its own timeout:
+//
+//	"preRun": ["say focus", {"command": "git commit -am wip", "timeout": "1m"}]
+type hookCommand struct {
+	Command string `json:"command"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func (h *hookCommand) UnmarshalJSON(raw []byte) error {
+	var command string
+	if err := json.Unmarshal(raw, &command); err == nil {
+		*h = hookCommand{Command: command}
+		return nil
+	}
+
+	type plain hookCommand
+	var full plain
+	if err := json.Unmarshal(raw, &full); err != nil {
+		return errors.New("hook must be a command string or an object with a command")
+	}
+	*h = hookCommand(full)
+	return nil
+}
+
+func (h hookCommand) MarshalJSON() ([]byte, error) {
+	if h.Timeout == "" {
+		return json.Marshal(h.Command)
+	}
+	type plain hookCommand
+	return json.Marshal(plain(h))
+}
+
+func (c hooksConfig) empty() bool {
+	return len(c.PreRun) == 0 && len(c.PostRun) == 0 && len(c.PerApp) == 0
+}
+
+func (c hooksConfig) validate() error {
+	if _, err := parseHookTimeout(c.Timeout); err != nil {
+		return err
+	}
+	switch c.OnPreRunFailure {
+	case "", hookFailureAbort, hookFailureContinue:
+	default:
+		return fmt.Errorf("invalid hooks.onPreRunFailure %q: expected abort or continue", c.OnPreRunFailure)
+	}
+
+	stages := []struct {
+		name     string
+		commands []hookCommand
+	}{{"preRun", c.PreRun}, {"postRun", c.PostRun}, {"perApp", c.PerApp}}
+	for _, stage := range stages {
+		for i, hook := range stage.commands {
+			if strings.TrimSpace(hook.Command) == "" {
+				return fmt.Errorf("hooks.%s[%d] has an empty command", stage.name, i)
+			}
+			if _, err := parseHookTimeout(hook.Timeout); err != nil {
+				return fmt.Errorf("hooks.%s[%d]: %w", stage.name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func parseHookTimeout(raw string) (time.Duration, error) {
+	if strings.TrimSpace(raw) == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid hook timeout %q: expected a positive duration such as 10s", raw)
+	}
+	return timeout, nil
+}
+
+func loadHooksFromConfig(path string, required bool) (hooksConfig, error) {
+	if strings.TrimSpace(path) == "" {
+		return hooksConfig{}, nil
+	}
+	cfg, err := readConfigFile(path, required)
+	if err != nil {
+		return hooksConfig{}, err
+	}
+	if cfg.Hooks == nil {
+		return hooksConfig{}, nil
+	}
+	if err := cfg.Hooks.validate(); err != nil {
+		return hooksConfig{}, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return *cfg.Hooks, nil
+}
+
+// hookRunner runs the configured hooks around closing apps. Hook output and
+// warnings go to log, keeping stdout clean for --output json.
+type hookRunner struct {
+	executor zencli.Executor
+	hooks    hooksConfig
+	profile  string
+	dryRun   bool
+	log      io.Writer
+}
+
+func (r hookRunner) env(targets []string, app string) zencli.HookEnv {
+	return zencli.HookEnv{Targets: targets, App: app, Profile: reportProfile(r.profile), DryRun: r.dryRun}
+}
+
+func (r hookRunner) run(stage string, hook hookCommand, env zencli.HookEnv) error {
+	timeout, _ := parseHookTimeout(hook.Timeout)
+	if timeout == 0 {
+		timeout, _ = parseHookTimeout(r.hooks.Timeout)
+	}
+
+	out, err := zencli.RunHook(r.executor, hook.Command, env, timeout)
+	if len(out) > 0 {
+		r.log.Write(out)
+		if out[len(out)-1] != '\n' {
+			fmt.Fprintln(r.log)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook %q failed: %w", stage, hook.Command, err)
+	}
+	return nil
+}
+
+func (r hookRunner) preRun(targets []string) error {
+	for _, hook := range r.hooks.PreRun {
+		err := r.run("preRun", hook, r.env(targets, ""))
+		if err == nil {
+			continue
+		}
+		if r.hooks.OnPreRunFailure != hookFailureContinue {
+			return err
+		}
+		fmt.Fprintf(r.log, "zen-cli: %v\n", err)
+	}
+	return nil
+}
+
+func (r hookRunner) perApp(targets []string, apps []string) {
+	for _, app := range apps {
+		for _, hook := range r.hooks.PerApp {
+			if err := r.run("perApp", hook, r.env(targets, app)); err != nil {
+				fmt.Fprintf(r.log, "zen-cli: %v\n", err)
+			}
+		}
+	}
+}
+
+func (r hookRunner) postRun(targets []string) {
+	for _, hook := range r.hooks.PostRun {
+		if err := r.run("postRun", hook, r.env(targets, "")); err != nil {
+			fmt.Fprintf(r.log, "zen-cli: %v\n", err)
+		}
+	}
+}
+
+// quitWithHooks previews the targets, runs the preRun hooks, closes the
+// targets and runs perApp hooks for every closed app, then the postRun
+// hooks. It returns like zencli.ExecuteWithOptions.
+func quitWithHooks(executor zencli.Executor, runner hookRunner, opts zencli.Options) ([]zencli.QuitResult, error) {
+	targets, err := zencli.PreviewWithOptions(executor, opts)
+	if err != nil {
+		return nil, err
+	}
+	return quitTargetsWithHooks(executor, runner, targets, opts)
+}
+
+// quitTargetsWithHooks is quitWithHooks for targets that were already
+// previewed and then narrowed down, for example by the user.
+func quitTargetsWithHooks(executor zencli.Executor, runner hookRunner, targets []zencli.AppInfo, opts zencli.Options) ([]zencli.QuitResult, error) {
+	names := zencli.AppNames(targets)
+	if err := runner.preRun(names); err != nil {
+		return nil, err
+	}
+
+	results, quitErr := zencli.QuitApps(executor, targets, opts)
+	runner.perApp(names, zencli.ClosedApps(results))
+	runner.postRun(names)
+	return results, quitErr
+}
+
+// previewWithHooks is the dry-run counterpart of quitWithHooks: hooks run
+// with ZEN_DRY_RUN=1 and perApp runs for every target.
+func previewWithHooks(executor zencli.Executor, runner hookRunner, opts zencli.Options) ([]zencli.AppInfo, error) {
+	targets, err := zencli.PreviewWithOptions(executor, opts)
+	if err != nil {
+		return nil, err
+	}
+	names := zencli.AppNames(targets)
+	runner.dryRun = true
+	if err := runner.preRun(names); err != nil {
+		return nil, err
+	}
+	runner.perApp(names, names)
+	runner.postRun(names)
+	return targets, nil
+}
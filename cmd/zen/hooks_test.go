@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingExecutor struct {
+	mu       sync.Mutex
+	commands []string
+	fail     map[string]error
+}
+
+// Run records hook invocations as "ZEN_APP|command".
+func (r *recordingExecutor) Run(name string, args ...string) ([]byte, error) {
+	command := args[len(args)-1]
+	app := ""
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "ZEN_APP=") {
+			app = strings.TrimPrefix(arg, "ZEN_APP=")
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, app+"|"+command)
+	return nil, r.fail[command]
+}
+
+func TestHookCommandUnmarshal(t *testing.T) {
+	raw := `{"preRun": ["say focus", {"command": "backup", "timeout": "1m"}], "onPreRunFailure": "continue"}`
+
+	var got hooksConfig
+	if err := json.Unmarshal([]byte(raw), &got); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := hooksConfig{
+		PreRun:          []hookCommand{{Command: "say focus"}, {Command: "backup", Timeout: "1m"}},
+		OnPreRunFailure: hookFailureContinue,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected hooks: got %+v want %+v", got, want)
+	}
+
+	out, err := json.Marshal(got.PreRun)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if string(out) != `["say focus",{"command":"backup","timeout":"1m"}]` {
+		t.Fatalf("unexpected json: %s", out)
+	}
+}
+
+func TestHooksConfigValidate(t *testing.T) {
+	tests := []hooksConfig{
+		{Timeout: "soon"},
+		{Timeout: "-1s"},
+		{OnPreRunFailure: "ignore"},
+		{PostRun: []hookCommand{{Command: " "}}},
+		{PerApp: []hookCommand{{Command: "echo", Timeout: "0s"}}},
+	}
+	for _, hooks := range tests {
+		if err := hooks.validate(); err == nil {
+			t.Fatalf("expected error for %+v", hooks)
+		}
+	}
+
+	valid := hooksConfig{Timeout: "10s", PreRun: []hookCommand{{Command: "echo", Timeout: "2s"}}}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestLoadHooksFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	raw := `{"allowedApps": ["Notes"], "hooks": {"postRun": ["echo done"]}}`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	got, err := loadHooksFromConfig(path, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := hooksConfig{PostRun: []hookCommand{{Command: "echo done"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected hooks: got %+v want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"hooks": {"timeout": "later"}}`), 0o644); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := loadHooksFromConfig(path, true); err == nil {
+		t.Fatal("expected invalid timeout error")
+	}
+}
+
+func TestHookRunnerPreRunAbortsByDefault(t *testing.T) {
+	executor := &recordingExecutor{fail: map[string]error{"check": errors.New("exit status 1")}}
+	var log bytes.Buffer
+	runner := hookRunner{
+		executor: executor,
+		hooks:    hooksConfig{PreRun: []hookCommand{{Command: "check"}, {Command: "after"}}},
+		log:      &log,
+	}
+
+	err := runner.preRun([]string{"Slack"})
+	if err == nil || !strings.Contains(err.Error(), `preRun hook "check" failed`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"|check"}; !reflect.DeepEqual(executor.commands, want) {
+		t.Fatalf("unexpected commands: got %v want %v", executor.commands, want)
+	}
+}
+
+func TestHookRunnerPreRunContinue(t *testing.T) {
+	executor := &recordingExecutor{fail: map[string]error{"check": errors.New("exit status 1")}}
+	var log bytes.Buffer
+	runner := hookRunner{
+		executor: executor,
+		hooks: hooksConfig{
+			PreRun:          []hookCommand{{Command: "check"}, {Command: "after"}},
+			OnPreRunFailure: hookFailureContinue,
+		},
+		log: &log,
+	}
+
+	if err := runner.preRun([]string{"Slack"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := []string{"|check", "|after"}; !reflect.DeepEqual(executor.commands, want) {
+		t.Fatalf("unexpected commands: got %v want %v", executor.commands, want)
+	}
+	if !strings.Contains(log.String(), `zen-cli: preRun hook "check" failed`) {
+		t.Fatalf("expected warning, got %q", log.String())
+	}
+}
+
+func TestHookRunnerPerAppAndPostRun(t *testing.T) {
+	executor := &recordingExecutor{fail: map[string]error{"notify": errors.New("exit status 1")}}
+	var log bytes.Buffer
+	runner := hookRunner{
+		executor: executor,
+		hooks: hooksConfig{
+			PerApp:  []hookCommand{{Command: "notify"}},
+			PostRun: []hookCommand{{Command: "done"}},
+		},
+		log: &log,
+	}
+
+	runner.perApp([]string{"Safari", "Slack"}, []string{"Safari", "Slack"})
+	runner.postRun([]string{"Safari", "Slack"})
+
+	want := []string{"Safari|notify", "Slack|notify", "|done"}
+	if !reflect.DeepEqual(executor.commands, want) {
+		t.Fatalf("unexpected commands: got %v want %v", executor.commands, want)
+	}
+	if strings.Count(log.String(), "perApp hook") != 2 {
+		t.Fatalf("expected two warnings, got %q", log.String())
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"zen-cli/internal/zencli"
+)
+
+var errSelectionCancelled = errors.New("selection cancelled; no apps were closed")
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// targetPicker is the state of the checkbox list. Every target starts
+// selected, meaning it will be quit.
+type targetPicker struct {
+	apps     []zencli.AppInfo
+	selected []bool
+	cursor   int
+}
+
+func newTargetPicker(apps []zencli.AppInfo) *targetPicker {
+	selected := make([]bool, len(apps))
+	for i := range selected {
+		selected[i] = true
+	}
+	return &targetPicker{apps: apps, selected: selected}
+}
+
+// handleKey applies one key to the picker and reports whether the selection
+// is finished or was cancelled.
+func (p *targetPicker) handleKey(key string) (done bool, cancelled bool) {
+	switch key {
+	case "up":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down":
+		if p.cursor < len(p.apps)-1 {
+			p.cursor++
+		}
+	case "space":
+		p.selected[p.cursor] = !p.selected[p.cursor]
+	case "all":
+		all := !p.allSelected()
+		for i := range p.selected {
+			p.selected[i] = all
+		}
+	case "enter":
+		return true, false
+	case "cancel":
+		return true, true
+	}
+	return false, false
+}
+
+func (p *targetPicker) allSelected() bool {
+	for _, selected := range p.selected {
+		if !selected {
+			return false
+		}
+	}
+	return true
+}
+
+// choice splits the targets into the apps to quit and the apps spared.
+func (p *targetPicker) choice() (quit []zencli.AppInfo, spared []zencli.AppInfo) {
+	for i, app := range p.apps {
+		if p.selected[i] {
+			quit = append(quit, app)
+		} else {
+			spared = append(spared, app)
+		}
+	}
+	return quit, spared
+}
+
+// pickerHelp is the first line of the picker; render draws it and one line
+// per app, which redraw relies on.
+const pickerHelp = "Select apps to quit (space: toggle, a: all, enter: confirm, q: cancel):"
+
+func (p *targetPicker) render(out io.Writer) {
+	fmt.Fprintf(out, "\r\x1b[K%s\r\n", pickerHelp)
+	for i, app := range p.apps {
+		cursor, check := " ", " "
+		if i == p.cursor {
+			cursor = ">"
+		}
+		if p.selected[i] {
+			check = "x"
+		}
+		fmt.Fprintf(out, "\r\x1b[K%s [%s] %s\r\n", cursor, check, app.Name)
+	}
+}
+
+func (p *targetPicker) redraw(out io.Writer) {
+	fmt.Fprintf(out, "\x1b[%dA", len(p.apps)+1)
+	p.render(out)
+}
+
+// readKey reads one key press from a terminal in raw mode.
+func readKey(in *bufio.Reader) (string, error) {
+	b, err := in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 0x1b:
+		if next, err := in.ReadByte(); err != nil || next != '[' {
+			return "cancel", nil
+		}
+		arrow, err := in.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch arrow {
+		case 'A':
+			return "up", nil
+		case 'B':
+			return "down", nil
+		}
+		return "", nil
+	case 'k':
+		return "up", nil
+	case 'j':
+		return "down", nil
+	case ' ':
+		return "space", nil
+	case 'a':
+		return "all", nil
+	case '\r', '\n':
+		return "enter", nil
+	case 'q', 0x03:
+		return "cancel", nil
+	}
+	return "", nil
+}
+
+// pickTargetsTerminal shows the checkbox list on a terminal.
+func pickTargetsTerminal(tty *os.File, in *bufio.Reader, out io.Writer, apps []zencli.AppInfo) ([]zencli.AppInfo, []zencli.AppInfo, error) {
+	restore, err := rawMode(tty)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer restore()
+
+	picker := newTargetPicker(apps)
+	picker.render(out)
+	for {
+		key, err := readKey(in)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read selection: %w", err)
+		}
+		done, cancelled := picker.handleKey(key)
+		if cancelled {
+			return nil, nil, errSelectionCancelled
+		}
+		if done {
+			quit, spared := picker.choice()
+			return quit, spared, nil
+		}
+		picker.redraw(out)
+	}
+}
+
+// rawMode switches tty to unbuffered, unechoed input with stty and returns
+// a function restoring the previous settings.
+func rawMode(tty *os.File) (func(), error) {
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(tty, "-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(tty, strings.TrimSpace(state))
+	}, nil
+}
+
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to configure terminal: %w", err)
+	}
+	return string(out), nil
+}
+
+// pickTargetsByLine is the prompt used when stdin is not a terminal: the
+// targets are numbered and the answer lists the numbers to keep open.
+func pickTargetsByLine(in *bufio.Reader, out io.Writer, apps []zencli.AppInfo) ([]zencli.AppInfo, []zencli.AppInfo, error) {
+	fmt.Fprintln(out, "zen-cli target apps:")
+	for i, app := range apps {
+		fmt.Fprintf(out, "%d) %s\n", i+1, app.Name)
+	}
+
+	for {
+		fmt.Fprint(out, "Numbers of apps to keep open (empty to quit all, q to cancel): ")
+		line, err := in.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			return nil, nil, errSelectionCancelled
+		}
+		if strings.TrimSpace(line) == "q" {
+			return nil, nil, errSelectionCancelled
+		}
+
+		keep, parseErr := parseSelection(line, len(apps))
+		if parseErr != nil {
+			fmt.Fprintf(out, "zen-cli: %v\n", parseErr)
+			if err != nil {
+				return nil, nil, parseErr
+			}
+			continue
+		}
+
+		var quit, spared []zencli.AppInfo
+		for i, app := range apps {
+			if keep[i] {
+				spared = append(spared, app)
+			} else {
+				quit = append(quit, app)
+			}
+		}
+		return quit, spared, nil
+	}
+}
+
+// parseSelection parses 1-based numbers separated by spaces or commas.
+func parseSelection(line string, count int) ([]bool, error) {
+	keep := make([]bool, count)
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > count {
+			return nil, fmt.Errorf("invalid choice %q: expected numbers between 1 and %d", field, count)
+		}
+		keep[n-1] = true
+	}
+	return keep, nil
+}
+
+// confirm asks a yes/no question. Anything but y or yes, including end of
+// input, is a no.
+func confirm(in *bufio.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	line, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// pickTargets lets the user deselect targets and, when asked to, adds the
+// spared apps to the allow-list of profile so the choice sticks. The profile
+// is re-read under the config lock, so apps added or removed while the user
+// was choosing are kept.
+func pickTargets(in *bufio.Reader, out io.Writer, tty *os.File, targets []zencli.AppInfo, configPath string, profile string) ([]zencli.AppInfo, error) {
+	if len(targets) == 0 {
+		return targets, nil
+	}
+
+	var quit, spared []zencli.AppInfo
+	var err error
+	if tty != nil {
+		quit, spared, err = pickTargetsTerminal(tty, in, out, targets)
+	} else {
+		quit, spared, err = pickTargetsByLine(in, out, targets)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spared) > 0 && confirm(in, out, "Remember this choice by allowing the apps you kept open?") {
+		err := updateConfigFile(configPath, func(cfg *configFile) error {
+			opts, err := cfg.profileOptions(profile)
+			if err != nil {
+				return err
+			}
+			entries := make([]string, 0, len(spared))
+			for _, app := range spared {
+				entries = append(entries, allowEntryFor(app))
+			}
+			updated := addAllowedApps(opts, entries)
+			if err := zencli.ValidatePatterns(updated.AllowedApps); err != nil {
+				return err
+			}
+			return cfg.setProfileOptions(profile, updated)
+		})
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(out, "zen-cli: added %s to the allow-list.\n", strings.Join(zencli.AppNames(spared), ", "))
+	}
+	return quit, nil
+}
+
+// allowEntryFor returns an allow-list entry matching exactly app: its bundle
+// ID when it is known, and otherwise its name as an anchored regular
+// expression, so glob characters in the name are not read as wildcards.
+func allowEntryFor(app zencli.AppInfo) string {
+	if app.BundleID != "" {
+		return "bundle:" + app.BundleID
+	}
+	return "re:^" + regexp.QuoteMeta(strings.TrimSpace(app.Name)) + "$"
+}
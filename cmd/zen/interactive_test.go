@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"zen-cli/internal/zencli"
+)
+
+func TestTargetPickerKeys(t *testing.T) {
+	picker := newTargetPicker(appInfoList("Safari", "Slack", "Music"))
+
+	for _, key := range []string{"down", "space", "down", "down", "space", "up"} {
+		if done, _ := picker.handleKey(key); done {
+			t.Fatalf("unexpected finish on %q", key)
+		}
+	}
+	done, cancelled := picker.handleKey("enter")
+	if !done || cancelled {
+		t.Fatalf("unexpected state: done %v cancelled %v", done, cancelled)
+	}
+
+	quit, spared := picker.choice()
+	if got := zencli.AppNames(quit); !reflect.DeepEqual(got, []string{"Safari"}) {
+		t.Fatalf("unexpected quit apps: %v", got)
+	}
+	if got := zencli.AppNames(spared); !reflect.DeepEqual(got, []string{"Slack", "Music"}) {
+		t.Fatalf("unexpected spared apps: %v", got)
+	}
+}
+
+func TestTargetPickerToggleAll(t *testing.T) {
+	picker := newTargetPicker(appInfoList("Safari", "Slack"))
+
+	picker.handleKey("all")
+	if quit, _ := picker.choice(); len(quit) != 0 {
+		t.Fatalf("expected nothing selected, got %v", quit)
+	}
+	picker.handleKey("all")
+	if quit, _ := picker.choice(); len(quit) != 2 {
+		t.Fatalf("expected everything selected, got %v", quit)
+	}
+}
+
+func TestTargetPickerRender(t *testing.T) {
+	picker := newTargetPicker(appInfoList("Safari", "Slack"))
+	picker.handleKey("space")
+
+	var out bytes.Buffer
+	picker.render(&out)
+	want := "\r\x1b[K" + pickerHelp + "\r\n" +
+		"\r\x1b[K> [ ] Safari\r\n" +
+		"\r\x1b[K  [x] Slack\r\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("\x1b[A\x1b[Bj \r\x03"))
+	var got []string
+	for i := 0; i < 6; i++ {
+		key, err := readKey(in)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		got = append(got, key)
+	}
+	want := []string{"up", "down", "down", "space", "enter", "cancel"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected keys: got %v want %v", got, want)
+	}
+}
+
+func TestPickTargetsByLine(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("4\n1, 3\n"))
+	var out bytes.Buffer
+
+	quit, spared, err := pickTargetsByLine(in, &out, appInfoList("Safari", "Slack", "Music"))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := zencli.AppNames(quit); !reflect.DeepEqual(got, []string{"Slack"}) {
+		t.Fatalf("unexpected quit apps: %v", got)
+	}
+	if got := zencli.AppNames(spared); !reflect.DeepEqual(got, []string{"Safari", "Music"}) {
+		t.Fatalf("unexpected spared apps: %v", got)
+	}
+	if !strings.Contains(out.String(), `invalid choice "4"`) {
+		t.Fatalf("expected invalid choice message, got %q", out.String())
+	}
+}
+
+func TestPickTargetsByLineCancel(t *testing.T) {
+	for _, input := range []string{"q\n", ""} {
+		in := bufio.NewReader(strings.NewReader(input))
+		_, _, err := pickTargetsByLine(in, &bytes.Buffer{}, appInfoList("Slack"))
+		if !errors.Is(err, errSelectionCancelled) {
+			t.Fatalf("unexpected error for %q: got %v want %v", input, err, errSelectionCancelled)
+		}
+	}
+}
+
+func TestPickTargetsRemembersChoice(t *testing.T) {
+	// Mail stands for an app another zen process added while the user was
+	// choosing.
+	path := writeTestConfig(t, `{"allowedApps": ["Notes", "Mail"]}`)
+	in := bufio.NewReader(strings.NewReader("2\ny\n"))
+
+	quit, err := pickTargets(in, &bytes.Buffer{}, nil, appInfoList("Safari", "Slack"), path, "")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := zencli.AppNames(quit); !reflect.DeepEqual(got, []string{"Safari"}) {
+		t.Fatalf("unexpected quit apps: %v", got)
+	}
+
+	saved, err := loadProfileFromConfig(path, defaultProfileName, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(saved.AllowedApps, []string{"Notes", "Mail", "re:^Slack$"}) {
+		t.Fatalf("unexpected allowed apps: %v", saved.AllowedApps)
+	}
+}
+
+func TestPickTargetsRemembersBracketedNames(t *testing.T) {
+	path := writeTestConfig(t, `{"allowedApps": ["Notes"]}`)
+	targets := []zencli.AppInfo{
+		{Name: "Foo [Beta]"},
+		{Name: "Bar [Nightly"},
+		{Name: "Baz *", BundleID: "com.example.baz"},
+		{Name: "Safari"},
+	}
+	in := bufio.NewReader(strings.NewReader("1 2 3\ny\n"))
+
+	if _, err := pickTargets(in, &bytes.Buffer{}, nil, targets, path, ""); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	saved, err := loadProfileFromConfig(path, defaultProfileName, true)
+	if err != nil {
+		t.Fatalf("expected the saved config to load, got %v", err)
+	}
+	want := []string{"Notes", `re:^Foo \[Beta\]$`, `re:^Bar \[Nightly$`, "bundle:com.example.baz"}
+	if !reflect.DeepEqual(saved.AllowedApps, want) {
+		t.Fatalf("unexpected allowed apps: got %q want %q", saved.AllowedApps, want)
+	}
+	for i, app := range targets[:3] {
+		pattern, err := zencli.ParsePattern(saved.AllowedApps[i+1])
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if !pattern.Match(app) {
+			t.Fatalf("expected %q to match %q", pattern.Raw, app.Name)
+		}
+		if pattern.Match(zencli.AppInfo{Name: "Foo B"}) {
+			t.Fatalf("expected %q not to match other apps", pattern.Raw)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := map[string]bool{"y\n": true, "Yes\n": true, "n\n": false, "\n": false, "": false}
+	for input, want := range tests {
+		got := confirm(bufio.NewReader(strings.NewReader(input)), &bytes.Buffer{}, "Continue?")
+		if got != want {
+			t.Fatalf("unexpected answer for %q: got %v want %v", input, got, want)
+		}
+	}
+}
+
+func appInfoList(names ...string) []zencli.AppInfo {
+	apps := make([]zencli.AppInfo, 0, len(names))
+	for _, name := range names {
+		apps = append(apps, zencli.AppInfo{Name: name})
+	}
+	return apps
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"zen-cli/internal/zencli"
+)
+
+const (
+	layerBuiltIn = "built-in"
+	layerSystem  = "system"
+	layerUser    = "user"
+	layerProject = "project"
+	layerEnv     = "env"
+	layerFlags   = "flags"
+)
+
+const (
+	configDirsEnv     = "ZEN_CONFIG_DIRS"
+	allowEnv          = "ZEN_ALLOW"
+	disallowEnv       = "ZEN_DISALLOW"
+	projectConfigBase = ".zen"
+)
+
+// defaultSystemConfigDir holds the shared baseline when ZEN_CONFIG_DIRS is
+// unset.
+var defaultSystemConfigDir = filepath.Join("/etc", "zen-cli")
+
+// optionLayer is one source of options. Layers are merged in order: lists
+// accumulate and later layers win for single values.
+type optionLayer struct {
+	name   string
+	source string
+	opts   zencli.Options
+	// replaceSet tells whether the layer decides ReplaceDefaultAllowed.
+	// Config files only do so when they set it to true, so a user config
+	// cannot silently undo a system baseline.
+	replaceSet bool
+}
+
+// mergeLayers merges layers from lowest to highest precedence. Escalation
+// only comes from flags, so it is taken from the last layer.
+func mergeLayers(layers []optionLayer) zencli.Options {
+	var merged zencli.Options
+	for _, layer := range layers {
+		merged.AllowedApps = append(merged.AllowedApps, layer.opts.AllowedApps...)
+		merged.DisallowedApps = append(merged.DisallowedApps, layer.opts.DisallowedApps...)
+		if layer.replaceSet {
+			merged.ReplaceDefaultAllowed = layer.opts.ReplaceDefaultAllowed
+		}
+		if layer.opts.Concurrency > 0 {
+			merged.Concurrency = layer.opts.Concurrency
+		}
+		merged.Escalation = layer.opts.Escalation
+	}
+	if merged.AllowedApps == nil {
+		merged.AllowedApps = []string{}
+	}
+	if merged.DisallowedApps == nil {
+		merged.DisallowedApps = []string{}
+	}
+	return merged
+}
+
+// systemConfigPaths lists the system config files, from ZEN_CONFIG_DIRS (a
+// colon-separated list of directories, applied in order) or /etc/zen-cli.
+func systemConfigPaths() []string {
+	dirs := filepath.SplitList(os.Getenv(configDirsEnv))
+	if len(dirs) == 0 {
+		dirs = []string{defaultSystemConfigDir}
+	}
+
+	paths := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if strings.TrimSpace(dir) == "" {
+			continue
+		}
+		paths = append(paths, findConfigFile(dir, "config"))
+	}
+	return paths
+}
+
+// findProjectConfig returns the nearest .zen.json, .zen.yaml, .zen.yml or
+// .zen.toml in dir or one of its parents.
+func findProjectConfig(dir string) (string, bool) {
+	for {
+		for _, ext := range configExtensions {
+			path := filepath.Join(dir, projectConfigBase+ext)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				return path, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// configLayerFiles returns the config files in merge order, keyed by layer.
+func configLayerFiles(userPath string) []optionLayer {
+	var files []optionLayer
+	for _, path := range systemConfigPaths() {
+		files = append(files, optionLayer{name: layerSystem, source: path})
+	}
+	if strings.TrimSpace(userPath) != "" {
+		files = append(files, optionLayer{name: layerUser, source: userPath})
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if path, ok := findProjectConfig(cwd); ok && path != userPath {
+			files = append(files, optionLayer{name: layerProject, source: path})
+		}
+	}
+	return files
+}
+
+// loadFileLayers reads files, which only the user config may require to
+// exist. A layer uses profile when it defines it and its top-level lists
+// otherwise; a named profile must be defined by at least one layer.
+func loadFileLayers(files []optionLayer, profile string, userRequired bool) ([]optionLayer, error) {
+	layers := make([]optionLayer, 0, len(files))
+	found := isDefaultProfile(profile)
+	for _, layer := range files {
+		cfg, err := readConfigFile(layer.source, layer.name == layerUser && userRequired)
+		if err != nil {
+			return nil, err
+		}
+
+		name := defaultProfileName
+		if cfg.hasProfile(profile) {
+			name, found = profile, true
+		}
+		opts, err := cfg.profileOptions(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := validatePatterns(opts); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", layer.source, err)
+		}
+
+		layer.opts = opts
+		layer.replaceSet = opts.ReplaceDefaultAllowed
+		layers = append(layers, layer)
+	}
+	if !found {
+		return nil, fmt.Errorf("profile %q not found", strings.TrimSpace(profile))
+	}
+	return layers, nil
+}
+
+// envLayer reads ZEN_ALLOW and ZEN_DISALLOW, comma-separated like --allow.
+func envLayer() (optionLayer, bool, error) {
+	allowed, disallowed := parseAllowApps(os.Getenv(allowEnv)), parseAllowApps(os.Getenv(disallowEnv))
+	if len(allowed) == 0 && len(disallowed) == 0 {
+		return optionLayer{}, false, nil
+	}
+	if err := zencli.ValidatePatterns(append(append([]string{}, allowed...), disallowed...)); err != nil {
+		return optionLayer{}, false, fmt.Errorf("invalid %s or %s: %w", allowEnv, disallowEnv, err)
+	}
+	return optionLayer{
+		name:   layerEnv,
+		source: allowEnv + "/" + disallowEnv,
+		opts:   zencli.Options{AllowedApps: allowed, DisallowedApps: disallowed},
+	}, true, nil
+}
+
+// loadConfigLayers returns every configured layer below the command-line
+// flags: system, user, project and environment.
+func loadConfigLayers(userPath string, profile string, userRequired bool) ([]optionLayer, error) {
+	layers, err := loadFileLayers(configLayerFiles(userPath), profile, userRequired)
+	if err != nil {
+		return nil, err
+	}
+	env, ok, err := envLayer()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		layers = append(layers, env)
+	}
+	return layers, nil
+}
+
+// loadLayeredOptions merges the configured layers of profile.
+func loadLayeredOptions(userPath string, profile string, userRequired bool) (zencli.Options, error) {
+	layers, err := loadConfigLayers(userPath, profile, userRequired)
+	if err != nil {
+		return zencli.Options{}, err
+	}
+	return mergeLayers(layers), nil
+}
+
+// appOrigin tells which layer contributed an entry of the effective lists.
+type appOrigin struct {
+	App    string `json:"app"`
+	List   string `json:"list"`
+	Layer  string `json:"layer"`
+	Source string `json:"source,omitempty"`
+}
+
+// layerOrigins attributes every effective allowed app and every disallowed
+// entry to the first layer that listed it. Allowed apps no layer listed
+// come from the built-in defaults.
+func layerOrigins(layers []optionLayer) []appOrigin {
+	merged := mergeLayers(layers)
+	origins := make([]appOrigin, 0, len(merged.AllowedApps)+len(merged.DisallowedApps))
+
+	for _, app := range zencli.EffectiveAllowedApps(merged) {
+		origin := appOrigin{App: app, List: statusAllowed, Layer: layerBuiltIn}
+		if layer, ok := firstLayerListing(layers, app, false); ok {
+			origin.Layer, origin.Source = layer.name, layer.source
+		}
+		origins = append(origins, origin)
+	}
+
+	seen := make(map[string]struct{})
+	for _, app := range merged.DisallowedApps {
+		key := strings.ToLower(strings.TrimSpace(app))
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		layer, _ := firstLayerListing(layers, app, true)
+		origins = append(origins, appOrigin{App: app, List: "disallowed", Layer: layer.name, Source: layer.source})
+	}
+	return origins
+}
+
+func firstLayerListing(layers []optionLayer, app string, disallowed bool) (optionLayer, bool) {
+	for _, layer := range layers {
+		list := layer.opts.AllowedApps
+		if disallowed {
+			list = layer.opts.DisallowedApps
+		}
+		for _, entry := range list {
+			if strings.EqualFold(strings.TrimSpace(entry), strings.TrimSpace(app)) {
+				return layer, true
+			}
+		}
+	}
+	return optionLayer{}, false
+}
+
+func printOrigins(out io.Writer, origins []appOrigin) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "APP\tLIST\tLAYER\tSOURCE")
+	for _, origin := range origins {
+		source := origin.Source
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", origin.App, origin.List, origin.Layer, source)
+	}
+	return tw.Flush()
+}
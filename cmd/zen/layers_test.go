@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"zen-cli/internal/zencli"
+)
+
+func TestMergeLayers(t *testing.T) {
+	layers := []optionLayer{
+		{name: layerSystem, opts: zencli.Options{AllowedApps: []string{"Slack"}, ReplaceDefaultAllowed: true}, replaceSet: true},
+		{name: layerUser, opts: zencli.Options{AllowedApps: []string{"Notes"}, Concurrency: 2}},
+		{name: layerEnv, opts: zencli.Options{DisallowedApps: []string{"Slack"}}},
+	}
+
+	got := mergeLayers(layers)
+	want := zencli.Options{
+		AllowedApps:           []string{"Slack", "Notes"},
+		DisallowedApps:        []string{"Slack"},
+		ReplaceDefaultAllowed: true,
+		Concurrency:           2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected options: got %+v want %+v", got, want)
+	}
+}
+
+func TestSystemConfigPaths(t *testing.T) {
+	t.Setenv(configDirsEnv, strings.Join([]string{"/opt/zen", "", "/srv/zen"}, string(os.PathListSeparator)))
+	want := []string{filepath.Join("/opt/zen", "config.json"), filepath.Join("/srv/zen", "config.json")}
+	if got := systemConfigPaths(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected paths: got %v want %v", got, want)
+	}
+
+	t.Setenv(configDirsEnv, "")
+	want = []string{filepath.Join(defaultSystemConfigDir, "config.json")}
+	if got := systemConfigPaths(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected paths: got %v want %v", got, want)
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "repo", "src", "pkg")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := filepath.Join(root, "repo", projectConfigBase+".json")
+	if err := os.WriteFile(want, []byte(`{}`), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	got, ok := findProjectConfig(nested)
+	if !ok || got != want {
+		t.Fatalf("unexpected project config: got %q, %v want %q", got, ok, want)
+	}
+}
+
+func TestLoadFileLayersProfileFromAnyLayer(t *testing.T) {
+	user := writeTestConfig(t, `{"allowedApps": ["Ghostty"]}`)
+	project := writeTestConfig(t, `{"allowedApps": ["Docker"], "profiles": {"coding": {"allowedApps": ["Xcode"]}}}`)
+	files := []optionLayer{
+		{name: layerSystem, source: filepath.Join(t.TempDir(), "missing.json")},
+		{name: layerUser, source: user},
+		{name: layerProject, source: project},
+	}
+
+	layers, err := loadFileLayers(files, "coding", true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	got := mergeLayers(layers).AllowedApps
+	if !reflect.DeepEqual(got, []string{"Ghostty", "Xcode"}) {
+		t.Fatalf("unexpected allowed apps: %v", got)
+	}
+
+	if _, err := loadFileLayers(files, "writing", true); err == nil {
+		t.Fatal("expected missing profile error")
+	}
+}
+
+func TestLoadFileLayersRejectsInvalidPatterns(t *testing.T) {
+	system := writeTestConfig(t, `{"allowedApps": ["re:(x"]}`)
+	_, err := loadFileLayers([]optionLayer{{name: layerSystem, source: system}}, "", false)
+	if err == nil || !strings.Contains(err.Error(), system) {
+		t.Fatalf("expected invalid config error naming %s, got %v", system, err)
+	}
+}
+
+func TestEnvLayer(t *testing.T) {
+	t.Setenv(allowEnv, "Xcode, Simulator")
+	t.Setenv(disallowEnv, "")
+
+	layer, ok, err := envLayer()
+	if err != nil || !ok {
+		t.Fatalf("unexpected result: ok %v err %v", ok, err)
+	}
+	if !reflect.DeepEqual(layer.opts.AllowedApps, []string{"Xcode", "Simulator"}) {
+		t.Fatalf("unexpected allowed apps: %v", layer.opts.AllowedApps)
+	}
+
+	t.Setenv(allowEnv, "")
+	if _, ok, _ := envLayer(); ok {
+		t.Fatal("expected no env layer")
+	}
+
+	t.Setenv(disallowEnv, "re:(")
+	if _, _, err := envLayer(); err == nil {
+		t.Fatal("expected invalid pattern error")
+	}
+}
+
+func TestLayerOrigins(t *testing.T) {
+	layers := []optionLayer{
+		{name: layerSystem, source: "/etc/zen-cli/config.json", opts: zencli.Options{AllowedApps: []string{"Slack", "Notes"}}},
+		{name: layerUser, source: "/home/me/.config/zen-cli/config.json", opts: zencli.Options{AllowedApps: []string{"notes"}}},
+		{name: layerEnv, source: "ZEN_ALLOW/ZEN_DISALLOW", opts: zencli.Options{DisallowedApps: []string{"Slack"}}},
+	}
+
+	var out bytes.Buffer
+	if err := printOrigins(&out, layerOrigins(layers)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := "APP               LIST        LAYER     SOURCE\n" +
+		"Terminal          allowed     built-in  -\n" +
+		"iTerm2            allowed     built-in  -\n" +
+		"Ghostty           allowed     built-in  -\n" +
+		"Finder            allowed     built-in  -\n" +
+		"Dock              allowed     built-in  -\n" +
+		"System Settings   allowed     built-in  -\n" +
+		"Activity Monitor  allowed     built-in  -\n" +
+		"Notes             allowed     system    /etc/zen-cli/config.json\n" +
+		"Slack             disallowed  env       ZEN_ALLOW/ZEN_DISALLOW\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -9,6 +10,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"zen-cli/internal/zencli"
 )
@@ -16,25 +19,68 @@ import (
 type zenCommand string
 
 const (
-	commandRun    zenCommand = "run"
-	commandList   zenCommand = "list"
-	commandAdd    zenCommand = "add"
-	commandRemove zenCommand = "remove"
-	commandHelp   zenCommand = "help"
+	commandRun      zenCommand = "run"
+	commandList     zenCommand = "list"
+	commandAdd      zenCommand = "add"
+	commandRemove   zenCommand = "remove"
+	commandProfile  zenCommand = "profile"
+	commandSession  zenCommand = "session"
+	commandWatch    zenCommand = "watch"
+	commandHistory  zenCommand = "history"
+	commandStats    zenCommand = "stats"
+	commandRestore  zenCommand = "restore"
+	commandConfig   zenCommand = "config"
+	commandSchedule zenCommand = "schedule"
+	commandDaemon   zenCommand = "daemon"
+	commandHelp     zenCommand = "help"
 )
 
 type parsedArgs struct {
-	command       zenCommand
-	commandApps   []string
-	helpTopic     string
-	options       zencli.Options
-	dryRun        bool
-	configPath    string
-	configPathSet bool
-	allowOnlySet  bool
+	command          zenCommand
+	commandApps      []string
+	action           string
+	actionArgs       []string
+	helpTopic        string
+	profile          string
+	output           outputFormat
+	sessionDuration  time.Duration
+	detach           bool
+	sessionStartedAt time.Time
+	watchInterval    time.Duration
+	watchFor         time.Duration
+	watchUntil       string
+	historySince     time.Duration
+	historyApp       string
+	statsTop         int
+	restoreID        int
+	scheduleAt       string
+	options          zencli.Options
+	dryRun           bool
+	interactive      bool
+	assumeYes        bool
+	effective        bool
+	origin           bool
+	configPath       string
+	configPathSet    bool
+	allowOnlySet     bool
 }
 
+// configFile is the on-disk config. Its top-level lists form the default
+// profile; named profiles live under "profiles".
 type configFile struct {
+	Version int `json:"version"`
+	profileConfig
+	Profiles map[string]profileConfig `json:"profiles,omitempty"`
+	Hooks    *hooksConfig             `json:"hooks,omitempty"`
+	// Schedules apply profiles at set times while zen daemon runs. The
+	// first active rule wins.
+	Schedules []scheduleConfig `json:"schedules,omitempty"`
+	// ConfirmBeforeQuit and ConfirmNonInteractive form the confirmPolicy.
+	ConfirmBeforeQuit     bool   `json:"confirmBeforeQuit,omitempty"`
+	ConfirmNonInteractive string `json:"confirmNonInteractive,omitempty"`
+}
+
+type profileConfig struct {
 	AllowedApps           []string `json:"allowedApps"`
 	DisallowedApps        []string `json:"disallowedApps"`
 	ReplaceDefaultAllowed bool     `json:"replaceDefaultAllowed"`
@@ -61,21 +107,96 @@ func main() {
 		}
 	}
 
-	if parsed.command == commandAdd || parsed.command == commandRemove {
-		configOpts, err := loadOptionsFromConfig(configPath, false)
-		if err != nil {
+	if parsed.command == commandProfile {
+		if err := runProfileCommand(os.Stdout, configPath, parsed.action, parsed.actionArgs); err != nil {
 			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
 			os.Exit(1)
 		}
+		return
+	}
 
-		var updated zencli.Options
-		if parsed.command == commandAdd {
-			updated = addAllowedApps(configOpts, parsed.commandApps)
-		} else {
-			updated = removeAllowedApps(configOpts, parsed.commandApps)
+	if parsed.command == commandConfig {
+		if err := runConfigCommand(os.Stdout, configPath, parsed); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if parsed.command == commandSchedule {
+		if err := runScheduleCommand(os.Stdout, configPath, parsed, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
 		}
+		return
+	}
 
-		if err := saveOptionsToConfig(configPath, updated); err != nil {
+	if parsed.command == commandDaemon {
+		if err := runDaemonCommand(os.Stdout, zencli.OSExecutor{}, configPath, parsed); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if parsed.command == commandSession {
+		if err := runSessionCommand(os.Stdout, zencli.OSExecutor{}, configPath, parsed); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if parsed.command == commandWatch {
+		if err := runWatchCommand(os.Stdout, zencli.OSExecutor{}, configPath, parsed); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if parsed.command == commandHistory {
+		if err := runHistoryCommand(os.Stdout, parsed); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if parsed.command == commandStats {
+		if err := runStatsCommand(os.Stdout, parsed); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if parsed.command == commandRestore {
+		if err := runRestoreCommand(os.Stdout, zencli.OSExecutor{}, parsed); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if parsed.command == commandAdd || parsed.command == commandRemove {
+		if parsed.command == commandAdd {
+			printPatternWarnings(os.Stderr, zencli.PatternWarnings(parsed.commandApps))
+		}
+		var updated zencli.Options
+		err := updateConfigFile(configPath, func(cfg *configFile) error {
+			configOpts, err := cfg.profileOptions(parsed.profile)
+			if err != nil {
+				return err
+			}
+			if parsed.command == commandAdd {
+				updated = addAllowedApps(configOpts, parsed.commandApps)
+			} else {
+				updated = removeAllowedApps(configOpts, parsed.commandApps)
+			}
+			return cfg.setProfileOptions(parsed.profile, updated)
+		})
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
 			os.Exit(1)
 		}
@@ -85,55 +206,108 @@ func main() {
 		return
 	}
 
-	configOpts, err := loadOptionsFromConfig(configPath, parsed.configPathSet)
+	layers, err := loadConfigLayers(configPath, parsed.profile, parsed.configPathSet)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	opts := mergeOptions(configOpts, parsed.options, parsed.allowOnlySet)
+	opts := mergeOptions(mergeLayers(layers), parsed.options, parsed.allowOnlySet)
 	if err := validateOptions(opts); err != nil {
 		fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
 		os.Exit(1)
 	}
 
+	allowed := zencli.EffectiveAllowedApps(opts)
 	if parsed.command == commandList {
-		printAllowedApps(os.Stdout, zencli.EffectiveAllowedApps(opts))
+		running, err := zencli.MatchRunningApps(zencli.OSExecutor{}, opts)
+		if err != nil && !errors.Is(err, zencli.ErrUnsupportedOS) {
+			fmt.Fprintf(os.Stderr, "zen-cli: could not list running apps: %v\n", err)
+		}
+		if err := printListReport(os.Stdout, parsed.output, parsed.profile, allowed, running); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
+	hooks, err := loadHooksFromConfig(configPath, parsed.configPathSet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+		os.Exit(1)
+	}
+	runner := hookRunner{executor: zencli.OSExecutor{}, hooks: hooks, profile: parsed.profile, log: os.Stderr}
+
 	if parsed.dryRun {
-		targets, err := zencli.PreviewWithOptions(zencli.OSExecutor{}, opts)
+		targets, err := previewWithHooks(zencli.OSExecutor{}, runner, opts)
 		if err != nil {
 			if errors.Is(err, zencli.ErrUnsupportedOS) {
-				fmt.Fprintln(os.Stderr, "zen-cli is macOS-only.")
+				fmt.Fprintln(os.Stderr, "zen-cli supports macOS and Linux only.")
 				os.Exit(2)
 			}
 			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
 			os.Exit(1)
 		}
-		printDryRunTargets(os.Stdout, targets)
+		recordHistory(historyEntry{
+			Time:      time.Now(),
+			Command:   historyDryRun,
+			Profile:   reportProfile(parsed.profile),
+			AllowHash: allowListHash(allowed),
+			Targets:   nonNil(zencli.AppNames(targets)),
+		})
+		if err := printDryRunReport(os.Stdout, parsed.output, parsed.profile, allowed, zencli.AppNames(targets)); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	killed, err := zencli.ExecuteWithOptions(zencli.OSExecutor{}, opts)
+	targets, err := zencli.PreviewWithOptions(zencli.OSExecutor{}, opts)
+	if errors.Is(err, zencli.ErrUnsupportedOS) {
+		fmt.Fprintln(os.Stderr, "zen-cli supports macOS and Linux only.")
+		os.Exit(2)
+	}
 	if err != nil {
-		if errors.Is(err, zencli.ErrUnsupportedOS) {
-			fmt.Fprintln(os.Stderr, "zen-cli is macOS-only.")
-			os.Exit(2)
-		}
 		fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	if len(killed) == 0 {
-		fmt.Println("zen-cli: no target apps were running.")
-		return
+	stdin := bufio.NewReader(os.Stdin)
+	if parsed.interactive {
+		var tty *os.File
+		if isTerminal(os.Stdin) {
+			tty = os.Stdin
+		}
+		targets, err = pickTargets(stdin, os.Stderr, tty, targets, configPath, parsed.profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else if !parsed.assumeYes {
+		policy, err := loadConfirmPolicy(configPath, parsed.configPathSet)
+		if err == nil {
+			err = confirmQuit(stdin, os.Stderr, isTerminal(os.Stdin), policy, targets)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	fmt.Println("zen-cli closed apps:")
-	for _, app := range killed {
-		fmt.Printf("- %s\n", app)
+	results, err := quitTargetsWithHooks(zencli.OSExecutor{}, runner, targets, opts)
+	if results == nil && err != nil {
+		fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+		os.Exit(1)
+	}
+	recordHistory(newRunHistoryEntry(historyRun, parsed.profile, allowed, results))
+	recordSnapshot(newSnapshot(historyRun, parsed.profile, results))
+	if err := printRunReport(os.Stdout, parsed.output, parsed.profile, allowed, results); err != nil {
+		fmt.Fprintf(os.Stderr, "zen-cli failed: %v\n", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "zen-cli failed: some apps could not be closed.")
+		os.Exit(1)
 	}
 }
 
@@ -159,28 +333,81 @@ func optionsFromArgs(args []string) (parsedArgs, error) {
 			if len(args) == 2 && isHelpToken(args[1]) {
 				return parsedArgs{command: commandHelp, helpTopic: string(commandList)}, nil
 			}
-			if len(args) != 1 {
-				return parsedArgs{}, errors.New("zen list does not accept extra arguments")
-			}
-			return parsedArgs{command: commandList}, nil
+			return parseListArgs(args[1:])
 		case string(commandAdd):
 			if len(args) == 2 && isHelpToken(args[1]) {
 				return parsedArgs{command: commandHelp, helpTopic: string(commandAdd)}, nil
 			}
-			apps := parseAppArgs(args[1:])
+			profile, rest, err := parseProfileFlag(commandAdd, args[1:])
+			if err != nil {
+				return parsedArgs{}, err
+			}
+			apps := parseAppArgs(rest)
 			if len(apps) == 0 {
 				return parsedArgs{}, errors.New("zen add requires at least one app name")
 			}
-			return parsedArgs{command: commandAdd, commandApps: apps}, nil
+			if err := zencli.ValidatePatterns(apps); err != nil {
+				return parsedArgs{}, err
+			}
+			return parsedArgs{command: commandAdd, commandApps: apps, profile: profile}, nil
 		case string(commandRemove):
 			if len(args) == 2 && isHelpToken(args[1]) {
 				return parsedArgs{command: commandHelp, helpTopic: string(commandRemove)}, nil
 			}
-			apps := parseAppArgs(args[1:])
+			profile, rest, err := parseProfileFlag(commandRemove, args[1:])
+			if err != nil {
+				return parsedArgs{}, err
+			}
+			apps := parseAppArgs(rest)
 			if len(apps) == 0 {
 				return parsedArgs{}, errors.New("zen remove requires at least one app name")
 			}
-			return parsedArgs{command: commandRemove, commandApps: apps}, nil
+			return parsedArgs{command: commandRemove, commandApps: apps, profile: profile}, nil
+		case string(commandProfile):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandProfile)}, nil
+			}
+			return parseProfileArgs(args[1:])
+		case string(commandSession):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandSession)}, nil
+			}
+			return parseSessionArgs(args[1:])
+		case string(commandWatch):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandWatch)}, nil
+			}
+			return parseWatchArgs(args[1:])
+		case string(commandHistory):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandHistory)}, nil
+			}
+			return parseHistoryArgs(args[1:])
+		case string(commandStats):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandStats)}, nil
+			}
+			return parseStatsArgs(args[1:])
+		case string(commandRestore):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandRestore)}, nil
+			}
+			return parseRestoreArgs(args[1:])
+		case string(commandConfig):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandConfig)}, nil
+			}
+			return parseConfigArgs(args[1:])
+		case string(commandSchedule):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandSchedule)}, nil
+			}
+			return parseScheduleArgs(args[1:])
+		case string(commandDaemon):
+			if len(args) == 2 && isHelpToken(args[1]) {
+				return parsedArgs{command: commandHelp, helpTopic: string(commandDaemon)}, nil
+			}
+			return parseDaemonArgs(args[1:])
 		}
 	}
 
@@ -193,6 +420,16 @@ func optionsFromArgs(args []string) (parsedArgs, error) {
 	list := fs.Bool("list", false, "print effective allow-list and exit")
 	dryRun := fs.Bool("dry-run", false, "show target apps and exit without closing")
 	config := fs.String("config", "", "path to config JSON file")
+	profile := fs.String("profile", "", "named profile from the config file")
+	output := fs.String("output", string(outputText), "output format: text, json or ndjson")
+	parallel := fs.Int("parallel", 1, "number of apps to quit at the same time")
+	grace := fs.Duration("grace", zencli.DefaultGracePeriod, "time an app gets to exit before escalating")
+	sigterm := fs.Bool("sigterm", false, "send SIGTERM before SIGKILL")
+	noForce := fs.Bool("no-force", false, "never send SIGKILL")
+	interactive := fs.Bool("interactive", false, "choose which targets to quit before quitting")
+	var yes bool
+	fs.BoolVar(&yes, "yes", false, "quit without asking for confirmation")
+	fs.BoolVar(&yes, "y", false, "quit without asking for confirmation")
 
 	if err := fs.Parse(args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
@@ -201,6 +438,11 @@ func optionsFromArgs(args []string) (parsedArgs, error) {
 		return parsedArgs{}, err
 	}
 
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return parsedArgs{}, err
+	}
+
 	seen := make(map[string]struct{})
 	fs.Visit(func(f *flag.Flag) {
 		seen[f.Name] = struct{}{}
@@ -213,21 +455,75 @@ func optionsFromArgs(args []string) (parsedArgs, error) {
 	if *list && *dryRun {
 		return parsedArgs{}, errors.New("--list and --dry-run cannot be used together")
 	}
+	if *interactive && (*list || *dryRun) {
+		return parsedArgs{}, errors.New("--interactive cannot be used with --list or --dry-run")
+	}
+	if *parallel < 1 {
+		return parsedArgs{}, errors.New("--parallel must be at least 1")
+	}
+	if *grace < 0 {
+		return parsedArgs{}, errors.New("--grace must not be negative")
+	}
+	allowApps, disallowApps := parseAllowApps(*allow), parseAllowApps(*disallow)
+	if err := zencli.ValidatePatterns(append(append([]string{}, allowApps...), disallowApps...)); err != nil {
+		return parsedArgs{}, err
+	}
 
 	return parsedArgs{
 		command: command,
 		options: zencli.Options{
-			AllowedApps:           parseAllowApps(*allow),
-			DisallowedApps:        parseAllowApps(*disallow),
+			AllowedApps:           allowApps,
+			DisallowedApps:        disallowApps,
 			ReplaceDefaultAllowed: *allowOnly,
+			Concurrency:           *parallel,
+			Escalation: zencli.EscalationPolicy{
+				GracePeriod: *grace,
+				Terminate:   *sigterm,
+				NoForce:     *noForce,
+			},
 		},
 		dryRun:        *dryRun,
+		interactive:   *interactive,
+		assumeYes:     yes,
+		profile:       strings.TrimSpace(*profile),
+		output:        format,
 		configPath:    strings.TrimSpace(*config),
 		configPathSet: hasFlag(seen, "config"),
 		allowOnlySet:  hasFlag(seen, "allow-only"),
 	}, nil
 }
 
+func parseListArgs(args []string) (parsedArgs, error) {
+	fs := flag.NewFlagSet("zen list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	profile := fs.String("profile", "", "named profile from the config file")
+	output := fs.String("output", string(outputText), "output format: text, json or ndjson")
+	if err := fs.Parse(args); err != nil {
+		return parsedArgs{}, err
+	}
+	if fs.NArg() != 0 {
+		return parsedArgs{}, errors.New("zen list does not accept extra arguments")
+	}
+
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return parsedArgs{}, err
+	}
+	return parsedArgs{command: commandList, profile: strings.TrimSpace(*profile), output: format}, nil
+}
+
+// parseProfileFlag parses the --profile flag accepted before the arguments
+// of add and remove.
+func parseProfileFlag(command zenCommand, args []string) (string, []string, error) {
+	fs := flag.NewFlagSet("zen "+string(command), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	profile := fs.String("profile", "", "named profile from the config file")
+	if err := fs.Parse(args); err != nil {
+		return "", nil, err
+	}
+	return strings.TrimSpace(*profile), fs.Args(), nil
+}
+
 func hasFlag(flags map[string]struct{}, name string) bool {
 	_, ok := flags[name]
 	return ok
@@ -239,83 +535,196 @@ func isHelpToken(arg string) bool {
 
 func defaultConfigPath() (string, error) {
 	if xdg := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); xdg != "" {
-		return filepath.Join(xdg, "zen-cli", "config.json"), nil
+		return findConfigFile(filepath.Join(xdg, "zen-cli"), "config"), nil
 	}
 
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve home directory: %w", err)
 	}
-	return filepath.Join(home, ".config", "zen-cli", "config.json"), nil
+	return findConfigFile(filepath.Join(home, ".config", "zen-cli"), "config"), nil
 }
 
-func loadOptionsFromConfig(path string, required bool) (zencli.Options, error) {
+func loadProfileFromConfig(path string, profile string, required bool) (zencli.Options, error) {
 	if strings.TrimSpace(path) == "" {
+		if !isDefaultProfile(profile) {
+			return zencli.Options{}, fmt.Errorf("profile %q not found: no config file", profile)
+		}
 		return zencli.Options{}, nil
 	}
 
+	cfg, err := readConfigFile(path, required)
+	if err != nil {
+		return zencli.Options{}, err
+	}
+	opts, err := cfg.profileOptions(profile)
+	if err != nil {
+		return zencli.Options{}, err
+	}
+	if err := validatePatterns(opts); err != nil {
+		return zencli.Options{}, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return opts, nil
+}
+
+func readConfigFile(path string, required bool) (configFile, error) {
 	raw, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) && !required {
-			return zencli.Options{}, nil
+			return configFile{}, nil
 		}
-		return zencli.Options{}, fmt.Errorf("failed to read config file %s: %w", path, err)
+		return configFile{}, fmt.Errorf("failed to read config file %s: %w", path, err)
 	}
 
-	var cfg configFile
-	if err := json.Unmarshal(raw, &cfg); err != nil {
-		return zencli.Options{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	raw, err = configJSON(path, raw)
+	if err == nil {
+		var cfg configFile
+		cfg, _, err = parseConfig(raw, strictConfigFromEnv())
+		if err == nil {
+			return cfg, nil
+		}
 	}
-
-	return zencli.Options{
-		AllowedApps:           cfg.AllowedApps,
-		DisallowedApps:        cfg.DisallowedApps,
-		ReplaceDefaultAllowed: cfg.ReplaceDefaultAllowed,
-	}, nil
+	return configFile{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
 }
 
-func saveOptionsToConfig(path string, opts zencli.Options) error {
+// updateConfigFile applies update to the stored config and writes it back,
+// keeping every section the update does not touch. The config stays locked
+// from read to write so concurrent updates are not lost.
+func updateConfigFile(path string, update func(cfg *configFile) error) error {
 	if strings.TrimSpace(path) == "" {
 		return errors.New("config path is empty")
 	}
 
-	cfg := configFile{
-		AllowedApps:           append([]string{}, opts.AllowedApps...),
-		DisallowedApps:        append([]string{}, opts.DisallowedApps...),
-		ReplaceDefaultAllowed: opts.ReplaceDefaultAllowed,
+	return withConfigLock(path, func() error {
+		cfg, err := readConfigFile(path, false)
+		if err != nil {
+			return err
+		}
+		if err := update(&cfg); err != nil {
+			return err
+		}
+		return writeConfigFile(path, cfg)
+	})
+}
+
+// withConfigLock runs fn while holding the config lock. Every change to the
+// config file goes through it.
+func withConfigLock(path string, fn func() error) error {
+	unlock, err := lockConfigFile(path)
+	if err != nil {
+		return err
 	}
+	defer unlock()
+	return fn()
+}
 
+func writeConfigFile(path string, cfg configFile) error {
+	body, err := marshalConfig(path, cfg)
+	if err != nil {
+		return err
+	}
+	return writeConfigBytes(path, body)
+}
+
+// marshalConfig encodes cfg in the format of path.
+func marshalConfig(path string, cfg configFile) ([]byte, error) {
+	cfg.Version = currentConfigVersion
 	body, err := json.MarshalIndent(cfg, "", "  ")
+	if err == nil {
+		body, err = encodeConfigJSON(path, append(body, '\n'))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return body, nil
+}
+
+// resolveConfigPath follows a symlinked config to the file it points at, so
+// writes replace that file instead of the link.
+func resolveConfigPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// lockConfigFile takes an advisory lock on path.lock, waiting for other zen
+// processes updating the same config. The returned func releases it.
+func lockConfigFile(path string) (func(), error) {
+	path = resolveConfigPath(path)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory %s: %w", dir, err)
+	}
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return nil, fmt.Errorf("failed to lock config file %s: %w", path, err)
 	}
-	body = append(body, '\n')
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock config file %s: %w", path, err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
 
+// writeConfigBytes replaces the config atomically: body is written to a
+// temporary file next to it, which is then renamed over path, so a crash
+// never leaves a truncated config. An existing config keeps its permissions.
+func writeConfigBytes(path string, body []byte) error {
+	path = resolveConfigPath(path)
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("failed to create config directory %s: %w", dir, err)
 	}
-	if err := os.WriteFile(path, body, 0o600); err != nil {
+
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	_, err = tmp.Write(body)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
 		return fmt.Errorf("failed to write config file %s: %w", path, err)
 	}
 	return nil
 }
 
+// mergeOptions puts the command-line options on top of the config.
 func mergeOptions(base zencli.Options, cli zencli.Options, allowOnlySet bool) zencli.Options {
-	merged := zencli.Options{
-		AllowedApps:           append([]string{}, base.AllowedApps...),
-		DisallowedApps:        append([]string{}, base.DisallowedApps...),
-		ReplaceDefaultAllowed: base.ReplaceDefaultAllowed,
-	}
-
-	merged.AllowedApps = append(merged.AllowedApps, cli.AllowedApps...)
-	merged.DisallowedApps = append(merged.DisallowedApps, cli.DisallowedApps...)
+	return mergeLayers([]optionLayer{
+		{name: layerUser, opts: base, replaceSet: true},
+		{name: layerFlags, opts: cli, replaceSet: allowOnlySet},
+	})
+}
 
-	if allowOnlySet {
-		merged.ReplaceDefaultAllowed = cli.ReplaceDefaultAllowed
+func printPatternWarnings(out io.Writer, warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintf(out, "zen-cli: warning: %s\n", warning)
 	}
+}
 
-	return merged
+func validatePatterns(opts zencli.Options) error {
+	return zencli.ValidatePatterns(append(append([]string{}, opts.AllowedApps...), opts.DisallowedApps...))
 }
 
 func validateOptions(opts zencli.Options) error {
@@ -412,39 +821,190 @@ func printDryRunTargets(out io.Writer, apps []string) {
 	}
 }
 
+func printClosedApps(out io.Writer, apps []string) {
+	if len(apps) == 0 {
+		fmt.Fprintln(out, "zen-cli: no target apps were running.")
+		return
+	}
+
+	fmt.Fprintln(out, "zen-cli closed apps:")
+	for _, app := range apps {
+		fmt.Fprintf(out, "- %s\n", app)
+	}
+}
+
+// printQuitSummary prints one row per target app with the outcome of each
+// closing step.
+func printQuitSummary(out io.Writer, results []zencli.QuitResult) {
+	if len(results) == 0 {
+		fmt.Fprintln(out, "zen-cli: no target apps were running.")
+		return
+	}
+
+	fmt.Fprintln(out, "zen-cli results:")
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "APP\tSTATUS\tGRACEFUL\tSIGTERM\tFORCE-KILL\tERROR")
+	for _, result := range results {
+		status, errText := statusClosed, ""
+		if result.Err != nil {
+			status, errText = statusFailed, result.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", result.App, status, result.Graceful, result.Terminate, result.ForceKill, errText)
+	}
+	tw.Flush()
+}
+
 func printHelp(out io.Writer, topic string) {
 	switch topic {
 	case string(commandList):
-		fmt.Fprintln(out, "Usage: zen list")
+		fmt.Fprintln(out, "Usage: zen list [--profile NAME]")
 		fmt.Fprintln(out)
-		fmt.Fprintln(out, "Show effective allowed apps without closing applications.")
+		fmt.Fprintln(out, "Show effective allowed apps, and which entry matched each running app, without closing applications.")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  --profile NAME              Show a named profile instead of the default")
+		fmt.Fprintln(out, "  --output FORMAT             Output format: text, json or ndjson")
 		return
 	case string(commandAdd):
 		fmt.Fprintln(out, "Usage: zen add APP_NAME [APP_NAME ...]")
 		fmt.Fprintln(out)
 		fmt.Fprintln(out, "Add app names to the allow-list and persist to config.")
+		fmt.Fprintln(out, "Entries may be globs such as \"Microsoft *\" or regular expressions prefixed with re:.")
+		fmt.Fprintln(out, "Prefix a name with name: to match it literally, for example \"name:Foo [Beta]\".")
+		fmt.Fprintln(out, "On macOS, bundle:ID matches the bundle identifier, e.g. bundle:com.tinyspeck.slackmacgap.")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  --profile NAME              Edit a named profile instead of the default")
 		return
 	case string(commandRemove):
 		fmt.Fprintln(out, "Usage: zen remove APP_NAME [APP_NAME ...]")
 		fmt.Fprintln(out)
 		fmt.Fprintln(out, "Remove app names from the allow-list and persist to config.")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  --profile NAME              Edit a named profile instead of the default")
+		return
+	case string(commandProfile):
+		fmt.Fprintln(out, "Usage:")
+		fmt.Fprintln(out, "  zen profile list")
+		fmt.Fprintln(out, "  zen profile create NAME")
+		fmt.Fprintln(out, "  zen profile delete NAME")
+		fmt.Fprintln(out, "  zen profile show [NAME]")
+		fmt.Fprintln(out, "  zen profile copy SOURCE TARGET")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Manage named allow-list profiles. The top-level config lists form the \"default\" profile.")
+		return
+	case string(commandSession):
+		fmt.Fprintln(out, "Usage:")
+		fmt.Fprintln(out, "  zen session start [--duration 50m] [--profile NAME] [--detach]")
+		fmt.Fprintln(out, "  zen session end")
+		fmt.Fprintln(out, "  zen session status")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Close distracting apps for a fixed time and relaunch them when the session ends.")
+		fmt.Fprintln(out, "Without --detach, start waits for the session to end, even after the terminal closes; Ctrl-C ends it early.")
+		fmt.Fprintln(out, "With --detach, a background process ends the session on time.")
+		fmt.Fprintln(out, "While it waits, config changes are picked up and apps they no longer allow are closed.")
+		return
+	case string(commandWatch):
+		fmt.Fprintln(out, "Usage: zen watch [--interval 10s] [--for DURATION | --until HH:MM] [--profile NAME]")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Keep closing apps outside the allow-list whenever they are relaunched.")
+		fmt.Fprintln(out, "Stops on Ctrl-C, SIGTERM or when the deadline passes. A scan that cannot list the running apps is")
+		fmt.Fprintln(out, "retried at the next interval; ten failures in a row stop the watch.")
+		fmt.Fprintln(out, "Config changes take effect at the next scan; an invalid change is reported and ignored.")
+		return
+	case string(commandHistory):
+		fmt.Fprintln(out, "Usage: zen history [--since 7d] [--app NAME] [--output FORMAT]")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Show past runs, dry-runs, sessions and watches, oldest first.")
+		fmt.Fprintln(out, "The log is kept in $XDG_STATE_HOME/zen-cli/history.jsonl.")
+		return
+	case string(commandStats):
+		fmt.Fprintln(out, "Usage: zen stats [--since 30d] [--top 10] [--output text|csv|json]")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Summarize the history log: focus sessions per day, total focus time,")
+		fmt.Fprintln(out, "the apps closed most often and the apps relaunched most often during a watch.")
+		return
+	case string(commandRestore):
+		fmt.Fprintln(out, "Usage:")
+		fmt.Fprintln(out, "  zen restore [--last | --id N] [--dry-run] [--output FORMAT]")
+		fmt.Fprintln(out, "  zen restore --list")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Reopen the apps a run, session start, watch or schedule window closed, skipping apps")
+		fmt.Fprintln(out, "that are already running. A watch or schedule window saves one snapshot when it ends.")
+		fmt.Fprintln(out, "Snapshots are kept in $XDG_STATE_HOME/zen-cli/snapshots.json.")
+		return
+	case string(commandConfig):
+		fmt.Fprintln(out, "Usage:")
+		fmt.Fprintln(out, "  zen config path")
+		fmt.Fprintln(out, "  zen config show [--effective | --origin] [--profile NAME]")
+		fmt.Fprintln(out, "  zen config edit")
+		fmt.Fprintln(out, "  zen config validate [PATH]")
+		fmt.Fprintln(out, "  zen config reset")
+		fmt.Fprintln(out, "  zen config migrate")
+		fmt.Fprintln(out, "  zen config convert --to json|yaml|toml")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Inspect and maintain the config file. edit opens $VISUAL or $EDITOR and only saves a valid config;")
+		fmt.Fprintln(out, "reset and migrate keep the previous file as a .bak copy next to it.")
+		fmt.Fprintln(out, "Set ZEN_CONFIG_STRICT=1 to reject unknown config keys instead of ignoring them.")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Configs may be JSON, YAML or TOML, chosen by extension. When a directory holds more than one,")
+		fmt.Fprintln(out, "config.json wins over config.yaml, config.yml and config.toml, in that order.")
+		fmt.Fprintln(out, "convert writes the config in another format and keeps the original as a .bak copy.")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Allow-lists are layered: system configs (/etc/zen-cli or $ZEN_CONFIG_DIRS), the user config,")
+		fmt.Fprintln(out, "the nearest .zen.json (or .yaml, .yml, .toml) above the current directory, then $ZEN_ALLOW and $ZEN_DISALLOW.")
+		fmt.Fprintln(out, "show --effective prints the merged result and show --origin the layer behind each app.")
+		return
+	case string(commandSchedule):
+		fmt.Fprintln(out, "Usage:")
+		fmt.Fprintln(out, "  zen schedule list")
+		fmt.Fprintln(out, "  zen schedule next [--at TIME]")
+		fmt.Fprintln(out, "  zen schedule check [--at TIME]")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Show the schedules in the config, the next one to start, or the one active at a time.")
+		fmt.Fprintln(out, "TIME is HH:MM today, YYYY-MM-DD HH:MM or RFC 3339; the default is now.")
+		fmt.Fprintln(out, "When several schedules are active, the first one in the config wins.")
+		return
+	case string(commandDaemon):
+		fmt.Fprintln(out, "Usage: zen daemon [--interval 10s]")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Keep running and, while a schedule is active, close apps outside its profile's allow-list")
+		fmt.Fprintln(out, "like zen watch. Config changes, including the schedules, take effect without a restart.")
 		return
 	}
 
 	fmt.Fprintln(out, "Usage:")
 	fmt.Fprintln(out, "  zen")
-	fmt.Fprintln(out, "  zen list")
+	fmt.Fprintln(out, "  zen list [--profile NAME]")
 	fmt.Fprintln(out, "  zen add APP_NAME [APP_NAME ...]")
 	fmt.Fprintln(out, "  zen remove APP_NAME [APP_NAME ...]")
-	fmt.Fprintln(out, "  zen help [list|add|remove]")
+	fmt.Fprintln(out, "  zen profile list|create|delete|show|copy")
+	fmt.Fprintln(out, "  zen session start|end|status")
+	fmt.Fprintln(out, "  zen watch [--interval 10s]")
+	fmt.Fprintln(out, "  zen history [--since 7d] [--app NAME]")
+	fmt.Fprintln(out, "  zen stats [--since 30d]")
+	fmt.Fprintln(out, "  zen restore [--last | --id N] [--dry-run]")
+	fmt.Fprintln(out, "  zen config path|show|edit|validate|reset|migrate|convert")
+	fmt.Fprintln(out, "  zen schedule list|next|check")
+	fmt.Fprintln(out, "  zen daemon [--interval 10s]")
+	fmt.Fprintln(out, "  zen help [list|add|remove|profile|session|watch|history|stats|restore|config|schedule|daemon]")
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, "Options:")
 	fmt.Fprintln(out, "  --dry-run                    Show target apps and exit without closing")
 	fmt.Fprintln(out, "  --config PATH               Use a specific config file path")
+	fmt.Fprintln(out, "  --profile NAME              Use a named profile from the config")
+	fmt.Fprintln(out, "  --output FORMAT             Output format: text, json or ndjson")
 	fmt.Fprintln(out, "  --allow APP1,APP2           Append allow apps for this run")
 	fmt.Fprintln(out, "  --allow-only                Use only explicitly allowed apps")
 	fmt.Fprintln(out, "  --disallow APP1,APP2        Remove allow apps for this run")
 	fmt.Fprintln(out, "  --list                      List effective allow apps (legacy)")
+	fmt.Fprintln(out, "  --parallel N                Quit up to N apps at the same time")
+	fmt.Fprintln(out, "  --grace DURATION            Time an app gets to exit before escalating (default 5s)")
+	fmt.Fprintln(out, "  --sigterm                   Send SIGTERM before SIGKILL to apps that do not exit")
+	fmt.Fprintln(out, "  --no-force                  Never SIGKILL; report apps that do not exit as failed")
+	fmt.Fprintln(out, "  --interactive               Choose which target apps to quit before quitting")
+	fmt.Fprintln(out, "  -y, --yes                   Do not ask for confirmation (see confirmBeforeQuit)")
 	fmt.Fprintln(out, "  -h, --help                  Show help")
 }
 
@@ -2,15 +2,31 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"zen-cli/internal/zencli"
 )
 
+// TestMain points the history and snapshot logs at a temporary directory so
+// tests that forget to set XDG_STATE_HOME never touch the real one.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "zen-cli-state-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create state dir: %v\n", err)
+		os.Exit(1)
+	}
+	os.Setenv("XDG_STATE_HOME", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestOptionsFromArgsDefault(t *testing.T) {
 	parsed, err := optionsFromArgs(nil)
 	if err != nil {
@@ -174,6 +190,46 @@ func TestOptionsFromArgsRemoveSubcommandHelp(t *testing.T) {
 	}
 }
 
+func TestOptionsFromArgsAddSubcommandWithProfile(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"add", "--profile", "coding", "Visual", "Studio", "Code"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.profile != "coding" {
+		t.Fatalf("unexpected profile: %q", parsed.profile)
+	}
+	want := []string{"Visual Studio Code"}
+	if !reflect.DeepEqual(parsed.commandApps, want) {
+		t.Fatalf("unexpected apps: got %v want %v", parsed.commandApps, want)
+	}
+}
+
+func TestOptionsFromArgsProfileFlag(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"--profile", "writing", "--dry-run"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.command != commandRun {
+		t.Fatalf("unexpected command: %s", parsed.command)
+	}
+	if parsed.profile != "writing" {
+		t.Fatalf("unexpected profile: %q", parsed.profile)
+	}
+}
+
+func TestOptionsFromArgsListSubcommandWithProfile(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"list", "--profile", "coding"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.command != commandList || parsed.profile != "coding" {
+		t.Fatalf("unexpected parse result: %+v", parsed)
+	}
+}
+
 func TestOptionsFromArgsRemoveRequiresApp(t *testing.T) {
 	_, err := optionsFromArgs([]string{"remove"})
 	if err == nil {
@@ -250,6 +306,71 @@ func TestOptionsFromArgsDryRunAndConfig(t *testing.T) {
 	}
 }
 
+func TestOptionsFromArgsOutputFlag(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"--dry-run", "--output", "json"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.output != outputJSON {
+		t.Fatalf("unexpected output format: %q", parsed.output)
+	}
+}
+
+func TestOptionsFromArgsListSubcommandOutput(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"list", "--output", "ndjson"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.command != commandList || parsed.output != outputNDJSON {
+		t.Fatalf("unexpected parse result: %+v", parsed)
+	}
+}
+
+func TestOptionsFromArgsUnknownOutput(t *testing.T) {
+	_, err := optionsFromArgs([]string{"--output", "xml"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestOptionsFromArgsParallelFlag(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"--parallel", "4"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.options.Concurrency != 4 {
+		t.Fatalf("unexpected concurrency: %d", parsed.options.Concurrency)
+	}
+}
+
+func TestOptionsFromArgsParallelDefault(t *testing.T) {
+	parsed, err := optionsFromArgs(nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.options.Concurrency != 1 {
+		t.Fatalf("unexpected concurrency: %d", parsed.options.Concurrency)
+	}
+}
+
+func TestOptionsFromArgsParallelRejectsZero(t *testing.T) {
+	_, err := optionsFromArgs([]string{"--parallel", "0"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestMergeOptionsConcurrencyFromCLI(t *testing.T) {
+	got := mergeOptions(zencli.Options{}, zencli.Options{Concurrency: 3}, false)
+	if got.Concurrency != 3 {
+		t.Fatalf("unexpected concurrency: %d", got.Concurrency)
+	}
+}
+
 func TestOptionsFromArgsListAndDryRunConflict(t *testing.T) {
 	_, err := optionsFromArgs([]string{"--list", "--dry-run"})
 	if err == nil {
@@ -257,6 +378,32 @@ func TestOptionsFromArgsListAndDryRunConflict(t *testing.T) {
 	}
 }
 
+func TestOptionsFromArgsInteractiveFlag(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"--interactive"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !parsed.interactive || parsed.command != commandRun {
+		t.Fatalf("unexpected args: %+v", parsed)
+	}
+
+	if _, err := optionsFromArgs([]string{"--interactive", "--dry-run"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestOptionsFromArgsYesFlag(t *testing.T) {
+	for _, flag := range []string{"--yes", "-y"} {
+		parsed, err := optionsFromArgs([]string{flag})
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if !parsed.assumeYes {
+			t.Fatalf("expected %s to set assumeYes", flag)
+		}
+	}
+}
+
 func TestDefaultConfigPathUsesXDGConfigHome(t *testing.T) {
 	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg-config")
 
@@ -328,25 +475,29 @@ func TestMergeOptionsAllowOnlyOverride(t *testing.T) {
 	}
 }
 
-func TestLoadOptionsFromConfigOptionalMissing(t *testing.T) {
-	got, err := loadOptionsFromConfig(filepath.Join(t.TempDir(), "missing.json"), false)
+func TestLoadLayeredOptionsOptionalMissing(t *testing.T) {
+	t.Setenv(configDirsEnv, t.TempDir())
+	got, err := loadLayeredOptions(filepath.Join(t.TempDir(), "missing.json"), defaultProfileName, false)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	if !reflect.DeepEqual(got, zencli.Options{}) {
-		t.Fatalf("unexpected options: %+v", got)
+	want := zencli.Options{AllowedApps: []string{}, DisallowedApps: []string{}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected options: got %+v want %+v", got, want)
 	}
 }
 
-func TestLoadOptionsFromConfigRequiredMissing(t *testing.T) {
-	_, err := loadOptionsFromConfig(filepath.Join(t.TempDir(), "missing.json"), true)
+func TestLoadLayeredOptionsRequiredMissing(t *testing.T) {
+	t.Setenv(configDirsEnv, t.TempDir())
+	_, err := loadLayeredOptions(filepath.Join(t.TempDir(), "missing.json"), defaultProfileName, true)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 }
 
-func TestLoadOptionsFromConfig(t *testing.T) {
+func TestLoadLayeredOptionsReadsUserConfig(t *testing.T) {
+	t.Setenv(configDirsEnv, t.TempDir())
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.json")
 	body := `{
@@ -358,7 +509,7 @@ func TestLoadOptionsFromConfig(t *testing.T) {
 		t.Fatalf("failed to write config: %v", err)
 	}
 
-	got, err := loadOptionsFromConfig(path, true)
+	got, err := loadLayeredOptions(path, defaultProfileName, true)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -373,7 +524,7 @@ func TestLoadOptionsFromConfig(t *testing.T) {
 	}
 }
 
-func TestSaveOptionsToConfigRoundtrip(t *testing.T) {
+func TestUpdateConfigFileRoundtrip(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.json")
 	opts := zencli.Options{
@@ -382,11 +533,14 @@ func TestSaveOptionsToConfigRoundtrip(t *testing.T) {
 		DisallowedApps:        []string{"Slack"},
 	}
 
-	if err := saveOptionsToConfig(path, opts); err != nil {
+	err := updateConfigFile(path, func(cfg *configFile) error {
+		return cfg.setProfileOptions(defaultProfileName, opts)
+	})
+	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	got, err := loadOptionsFromConfig(path, true)
+	got, err := loadProfileFromConfig(path, defaultProfileName, true)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -482,3 +636,53 @@ func TestPrintHelpAdd(t *testing.T) {
 		t.Fatalf("unexpected output: %q", got)
 	}
 }
+
+func TestOptionsFromArgsEscalationFlags(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"--grace", "2s", "--sigterm", "--no-force"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := zencli.EscalationPolicy{GracePeriod: 2 * time.Second, Terminate: true, NoForce: true}
+	if !reflect.DeepEqual(parsed.options.Escalation, want) {
+		t.Fatalf("unexpected escalation: got %+v want %+v", parsed.options.Escalation, want)
+	}
+}
+
+func TestOptionsFromArgsDefaultGrace(t *testing.T) {
+	parsed, err := optionsFromArgs(nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if parsed.options.Escalation.GracePeriod != zencli.DefaultGracePeriod {
+		t.Fatalf("unexpected grace period: %s", parsed.options.Escalation.GracePeriod)
+	}
+}
+
+func TestOptionsFromArgsRejectsNegativeGrace(t *testing.T) {
+	if _, err := optionsFromArgs([]string{"--grace", "-1s"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestOptionsFromArgsRejectsInvalidPattern(t *testing.T) {
+	if _, err := optionsFromArgs([]string{"--allow", "re:("}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if _, err := optionsFromArgs([]string{"add", "re:["}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestLoadLayeredOptionsRejectsInvalidPattern(t *testing.T) {
+	t.Setenv(configDirsEnv, t.TempDir())
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"allowedApps": ["Microsoft *"], "disallowedApps": ["re:(teams"]}`), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	_, err := loadLayeredOptions(path, defaultProfileName, true)
+	if err == nil || !strings.Contains(err.Error(), `invalid pattern "re:(teams"`) {
+		t.Fatalf("expected invalid pattern error, got %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"zen-cli/internal/zencli"
+)
+
+type outputFormat string
+
+const (
+	outputText   outputFormat = "text"
+	outputJSON   outputFormat = "json"
+	outputNDJSON outputFormat = "ndjson"
+)
+
+const (
+	statusAllowed = "allowed"
+	statusTarget  = "target"
+	statusClosed  = "closed"
+	statusFailed  = "failed"
+)
+
+func parseOutputFormat(raw string) (outputFormat, error) {
+	switch format := outputFormat(strings.ToLower(strings.TrimSpace(raw))); format {
+	case "", outputText:
+		return outputText, nil
+	case outputJSON, outputNDJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format %q: expected text, json or ndjson", raw)
+}
+
+// The report types below are the stable machine-readable schema of list,
+// dry-run and run. Slices are always present, never null, except the running
+// apps of list, which are omitted when they could not be listed.
+
+type listReport struct {
+	Command string       `json:"command"`
+	Profile string       `json:"profile"`
+	Allowed []string     `json:"allowed"`
+	Running []runningApp `json:"running,omitempty"`
+}
+
+// runningApp is a running app with the entry that decided its fate.
+type runningApp struct {
+	App      string `json:"app"`
+	BundleID string `json:"bundleId,omitempty"`
+	Status   string `json:"status"`
+	Pattern  string `json:"pattern,omitempty"`
+}
+
+type dryRunReport struct {
+	Command string   `json:"command"`
+	Profile string   `json:"profile"`
+	Allowed []string `json:"allowed"`
+	Targets []string `json:"targets"`
+}
+
+type runReport struct {
+	Command string      `json:"command"`
+	Profile string      `json:"profile"`
+	Allowed []string    `json:"allowed"`
+	Results []appResult `json:"results"`
+}
+
+type appResult struct {
+	App       string `json:"app"`
+	Status    string `json:"status"`
+	Graceful  string `json:"graceful"`
+	Terminate string `json:"terminate"`
+	ForceKill string `json:"forceKill"`
+	Error     string `json:"error,omitempty"`
+}
+
+// appRecord is one ndjson line: a single app with the command it belongs to.
+// The graceful, terminate and forceKill steps are only reported by run;
+// running and pattern only by list.
+type appRecord struct {
+	Command   string `json:"command"`
+	Profile   string `json:"profile"`
+	App       string `json:"app"`
+	BundleID  string `json:"bundleId,omitempty"`
+	Running   bool   `json:"running,omitempty"`
+	Status    string `json:"status"`
+	Pattern   string `json:"pattern,omitempty"`
+	Graceful  string `json:"graceful,omitempty"`
+	Terminate string `json:"terminate,omitempty"`
+	ForceKill string `json:"forceKill,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func reportProfile(profile string) string {
+	if isDefaultProfile(profile) {
+		return defaultProfileName
+	}
+	return profile
+}
+
+func printListReport(out io.Writer, format outputFormat, profile string, allowed []string, running []zencli.AppMatch) error {
+	runningApps := make([]runningApp, 0, len(running))
+	for _, match := range running {
+		runningApps = append(runningApps, runningApp{App: match.App, BundleID: match.BundleID, Status: string(match.Status), Pattern: match.Pattern})
+	}
+
+	switch format {
+	case outputJSON:
+		return writeJSON(out, listReport{
+			Command: string(commandList),
+			Profile: reportProfile(profile),
+			Allowed: nonNil(allowed),
+			Running: runningApps,
+		})
+	case outputNDJSON:
+		if err := writeRecords(out, string(commandList), profile, statusAllowed, allowed); err != nil {
+			return err
+		}
+		enc := json.NewEncoder(out)
+		for _, app := range runningApps {
+			record := appRecord{
+				Command:  string(commandList),
+				Profile:  reportProfile(profile),
+				App:      app.App,
+				BundleID: app.BundleID,
+				Running:  true,
+				Status:   app.Status,
+				Pattern:  app.Pattern,
+			}
+			if err := enc.Encode(record); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+		return nil
+	}
+	printAllowedApps(out, allowed)
+	printRunningApps(out, running)
+	return nil
+}
+
+func printRunningApps(out io.Writer, running []zencli.AppMatch) {
+	if len(running) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "zen-cli running apps:")
+	for _, match := range running {
+		switch {
+		case match.Pattern == "":
+			fmt.Fprintf(out, "- %s: %s\n", match.App, match.Status)
+		case match.Status == zencli.MatchAllowed:
+			fmt.Fprintf(out, "- %s: allowed by %q\n", match.App, match.Pattern)
+		default:
+			fmt.Fprintf(out, "- %s: target, disallowed by %q\n", match.App, match.Pattern)
+		}
+	}
+}
+
+func printDryRunReport(out io.Writer, format outputFormat, profile string, allowed []string, targets []string) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(out, dryRunReport{
+			Command: "dry-run",
+			Profile: reportProfile(profile),
+			Allowed: nonNil(allowed),
+			Targets: nonNil(targets),
+		})
+	case outputNDJSON:
+		return writeRecords(out, "dry-run", profile, statusTarget, targets)
+	}
+	printDryRunTargets(out, targets)
+	return nil
+}
+
+func printRunReport(out io.Writer, format outputFormat, profile string, allowed []string, results []zencli.QuitResult) error {
+	appResults := make([]appResult, 0, len(results))
+	for _, result := range results {
+		appResults = append(appResults, newAppResult(result))
+	}
+
+	switch format {
+	case outputJSON:
+		return writeJSON(out, runReport{
+			Command: string(commandRun),
+			Profile: reportProfile(profile),
+			Allowed: nonNil(allowed),
+			Results: appResults,
+		})
+	case outputNDJSON:
+		enc := json.NewEncoder(out)
+		for _, result := range appResults {
+			record := appRecord{
+				Command:   string(commandRun),
+				Profile:   reportProfile(profile),
+				App:       result.App,
+				Status:    result.Status,
+				Graceful:  result.Graceful,
+				Terminate: result.Terminate,
+				ForceKill: result.ForceKill,
+				Error:     result.Error,
+			}
+			if err := enc.Encode(record); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+		return nil
+	}
+	printQuitSummary(out, results)
+	return nil
+}
+
+func newAppResult(result zencli.QuitResult) appResult {
+	converted := appResult{
+		App:       result.App,
+		Status:    statusClosed,
+		Graceful:  string(result.Graceful),
+		Terminate: string(result.Terminate),
+		ForceKill: string(result.ForceKill),
+	}
+	if result.Err != nil {
+		converted.Status = statusFailed
+		converted.Error = result.Err.Error()
+	}
+	return converted
+}
+
+func writeJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+func writeRecords(out io.Writer, command string, profile string, status string, apps []string) error {
+	enc := json.NewEncoder(out)
+	for _, app := range apps {
+		record := appRecord{Command: command, Profile: reportProfile(profile), App: app, Status: status}
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return nil
+}
+
+func nonNil(apps []string) []string {
+	if apps == nil {
+		return []string{}
+	}
+	return apps
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"zen-cli/internal/zencli"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	cases := map[string]outputFormat{
+		"":       outputText,
+		"text":   outputText,
+		"JSON":   outputJSON,
+		"ndjson": outputNDJSON,
+	}
+	for raw, want := range cases {
+		got, err := parseOutputFormat(raw)
+		if err != nil {
+			t.Fatalf("expected nil error for %q, got %v", raw, err)
+		}
+		if got != want {
+			t.Fatalf("unexpected format for %q: got %q want %q", raw, got, want)
+		}
+	}
+
+	if _, err := parseOutputFormat("yaml"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestPrintListReportJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := printListReport(&out, outputJSON, "", []string{"Terminal"}, nil); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "{\n  \"command\": \"list\",\n  \"profile\": \"default\",\n  \"allowed\": [\n    \"Terminal\"\n  ]\n}\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintListReportRunningApps(t *testing.T) {
+	running := []zencli.AppMatch{
+		{App: "Microsoft Word", Status: zencli.MatchAllowed, Pattern: "Microsoft *"},
+		{App: "Slack", Status: zencli.MatchTarget, Pattern: "re:^Sl"},
+		{App: "zen", Status: zencli.MatchProtected},
+	}
+
+	var out bytes.Buffer
+	if err := printListReport(&out, outputText, "", []string{"Microsoft *"}, running); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "zen-cli allowed apps:\n- Microsoft *\n" +
+		"zen-cli running apps:\n" +
+		"- Microsoft Word: allowed by \"Microsoft *\"\n" +
+		"- Slack: target, disallowed by \"re:^Sl\"\n" +
+		"- zen: protected\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintListReportRunningAppsNDJSON(t *testing.T) {
+	running := []zencli.AppMatch{{App: "Microsoft Word", Status: zencli.MatchAllowed, Pattern: "Microsoft *"}}
+
+	var out bytes.Buffer
+	if err := printListReport(&out, outputNDJSON, "", []string{"Microsoft *"}, running); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := `{"command":"list","profile":"default","app":"Microsoft *","status":"allowed"}
+{"command":"list","profile":"default","app":"Microsoft Word","running":true,"status":"allowed","pattern":"Microsoft *"}
+`
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintDryRunReportJSONEmptyTargets(t *testing.T) {
+	var out bytes.Buffer
+	if err := printDryRunReport(&out, outputJSON, "coding", nil, nil); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "{\n  \"command\": \"dry-run\",\n  \"profile\": \"coding\",\n  \"allowed\": [],\n  \"targets\": []\n}\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintDryRunReportNDJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := printDryRunReport(&out, outputNDJSON, "", []string{"Terminal"}, []string{"Safari", "Slack"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := `{"command":"dry-run","profile":"default","app":"Safari","status":"target"}
+{"command":"dry-run","profile":"default","app":"Slack","status":"target"}
+`
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintRunReportJSON(t *testing.T) {
+	results := []zencli.QuitResult{
+		{App: "Music", Graceful: zencli.StepFailed, Terminate: zencli.StepSkipped, ForceKill: zencli.StepSkipped, Err: errors.New("failed to quit Music")},
+		{App: "Safari", Graceful: zencli.StepOK, Terminate: zencli.StepOK, ForceKill: zencli.StepNotRunning},
+	}
+
+	var out bytes.Buffer
+	if err := printRunReport(&out, outputJSON, "", []string{"Terminal"}, results); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "{\n  \"command\": \"run\",\n  \"profile\": \"default\",\n  \"allowed\": [\n    \"Terminal\"\n  ],\n" +
+		"  \"results\": [\n" +
+		"    {\n      \"app\": \"Music\",\n      \"status\": \"failed\",\n      \"graceful\": \"failed\",\n      \"terminate\": \"skipped\",\n      \"forceKill\": \"skipped\",\n      \"error\": \"failed to quit Music\"\n    },\n" +
+		"    {\n      \"app\": \"Safari\",\n      \"status\": \"closed\",\n      \"graceful\": \"ok\",\n      \"terminate\": \"ok\",\n      \"forceKill\": \"not-running\"\n    }\n" +
+		"  ]\n}\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintRunReportNDJSON(t *testing.T) {
+	results := []zencli.QuitResult{{App: "Safari", Graceful: zencli.StepOK, Terminate: zencli.StepSkipped, ForceKill: zencli.StepOK}}
+
+	var out bytes.Buffer
+	if err := printRunReport(&out, outputNDJSON, "coding", nil, results); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := `{"command":"run","profile":"coding","app":"Safari","status":"closed","graceful":"ok","terminate":"skipped","forceKill":"ok"}
+`
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintRunReportText(t *testing.T) {
+	results := []zencli.QuitResult{
+		{App: "Music", Graceful: zencli.StepFailed, Terminate: zencli.StepSkipped, ForceKill: zencli.StepSkipped, Err: errors.New("quit refused")},
+		{App: "Safari", Graceful: zencli.StepOK, Terminate: zencli.StepSkipped, ForceKill: zencli.StepOK},
+	}
+
+	var out bytes.Buffer
+	if err := printRunReport(&out, outputText, "", nil, results); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "zen-cli results:\n" +
+		"APP     STATUS  GRACEFUL  SIGTERM  FORCE-KILL  ERROR\n" +
+		"Music   failed  failed    skipped  skipped     quit refused\n" +
+		"Safari  closed  ok        skipped  ok          \n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintRunReportTextNoTargets(t *testing.T) {
+	var out bytes.Buffer
+	if err := printRunReport(&out, outputText, "", nil, nil); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "zen-cli: no target apps were running.\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
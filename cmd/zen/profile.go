@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"zen-cli/internal/zencli"
+)
+
+// defaultProfileName refers to the top-level lists of the config file.
+const defaultProfileName = "default"
+
+const (
+	profileActionList   = "list"
+	profileActionCreate = "create"
+	profileActionDelete = "delete"
+	profileActionShow   = "show"
+	profileActionCopy   = "copy"
+)
+
+func isDefaultProfile(name string) bool {
+	name = strings.TrimSpace(name)
+	return name == "" || name == defaultProfileName
+}
+
+func (p profileConfig) options() zencli.Options {
+	return zencli.Options{
+		AllowedApps:           p.AllowedApps,
+		DisallowedApps:        p.DisallowedApps,
+		ReplaceDefaultAllowed: p.ReplaceDefaultAllowed,
+	}
+}
+
+func profileFromOptions(opts zencli.Options) profileConfig {
+	return profileConfig{
+		AllowedApps:           append([]string{}, opts.AllowedApps...),
+		DisallowedApps:        append([]string{}, opts.DisallowedApps...),
+		ReplaceDefaultAllowed: opts.ReplaceDefaultAllowed,
+	}
+}
+
+func (cfg configFile) hasProfile(name string) bool {
+	if isDefaultProfile(name) {
+		return true
+	}
+	_, ok := cfg.Profiles[strings.TrimSpace(name)]
+	return ok
+}
+
+func (cfg configFile) profileOptions(name string) (zencli.Options, error) {
+	if isDefaultProfile(name) {
+		return cfg.profileConfig.options(), nil
+	}
+
+	profile, ok := cfg.Profiles[strings.TrimSpace(name)]
+	if !ok {
+		return zencli.Options{}, fmt.Errorf("profile %q not found", strings.TrimSpace(name))
+	}
+	return profile.options(), nil
+}
+
+func (cfg *configFile) setProfileOptions(name string, opts zencli.Options) error {
+	if isDefaultProfile(name) {
+		cfg.profileConfig = profileFromOptions(opts)
+		return nil
+	}
+
+	name = strings.TrimSpace(name)
+	if _, ok := cfg.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	cfg.Profiles[name] = profileFromOptions(opts)
+	return nil
+}
+
+// profileNames returns the default profile followed by named profiles in
+// alphabetical order.
+func (cfg configFile) profileNames() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{defaultProfileName}, names...)
+}
+
+func createProfile(cfg *configFile, name string, base profileConfig) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	if cfg.hasProfile(name) {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]profileConfig)
+	}
+	cfg.Profiles[name] = base
+	return nil
+}
+
+func deleteProfile(cfg *configFile, name string) error {
+	if isDefaultProfile(name) {
+		return errors.New("the default profile cannot be deleted")
+	}
+	if !cfg.hasProfile(name) {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	delete(cfg.Profiles, name)
+	if len(cfg.Profiles) == 0 {
+		cfg.Profiles = nil
+	}
+	return nil
+}
+
+func copyProfile(cfg *configFile, source string, target string) error {
+	opts, err := cfg.profileOptions(source)
+	if err != nil {
+		return err
+	}
+	return createProfile(cfg, target, profileFromOptions(opts))
+}
+
+func validateProfileName(name string) error {
+	if strings.TrimSpace(name) != name || name == "" {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	if isDefaultProfile(name) {
+		return fmt.Errorf("profile name %q is reserved", name)
+	}
+	return nil
+}
+
+func parseProfileArgs(args []string) (parsedArgs, error) {
+	if len(args) == 0 {
+		return parsedArgs{}, errors.New("zen profile requires an action: list, create, delete, show or copy")
+	}
+
+	action := args[0]
+	rest := args[1:]
+	switch action {
+	case profileActionList:
+		if len(rest) != 0 {
+			return parsedArgs{}, errors.New("zen profile list does not accept extra arguments")
+		}
+	case profileActionCreate, profileActionDelete:
+		if len(rest) != 1 {
+			return parsedArgs{}, fmt.Errorf("zen profile %s requires exactly one profile name", action)
+		}
+	case profileActionShow:
+		if len(rest) > 1 {
+			return parsedArgs{}, errors.New("zen profile show accepts at most one profile name")
+		}
+	case profileActionCopy:
+		if len(rest) != 2 {
+			return parsedArgs{}, errors.New("zen profile copy requires a source and a target profile name")
+		}
+	default:
+		return parsedArgs{}, fmt.Errorf("unknown profile action %q", action)
+	}
+
+	return parsedArgs{command: commandProfile, action: action, actionArgs: rest}, nil
+}
+
+func runProfileCommand(out io.Writer, configPath string, action string, args []string) error {
+	switch action {
+	case profileActionList:
+		cfg, err := readConfigFile(configPath, false)
+		if err != nil {
+			return err
+		}
+		printProfiles(out, cfg.profileNames())
+		return nil
+	case profileActionShow:
+		name := defaultProfileName
+		if len(args) == 1 {
+			name = args[0]
+		}
+		opts, err := loadProfileFromConfig(configPath, name, false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "zen-cli profile: %s\n", name)
+		printAllowedApps(out, zencli.EffectiveAllowedApps(opts))
+		return nil
+	}
+
+	err := updateConfigFile(configPath, func(cfg *configFile) error {
+		switch action {
+		case profileActionCreate:
+			return createProfile(cfg, args[0], profileFromOptions(zencli.Options{}))
+		case profileActionDelete:
+			return deleteProfile(cfg, args[0])
+		case profileActionCopy:
+			return copyProfile(cfg, args[0], args[1])
+		}
+		return fmt.Errorf("unknown profile action %q", action)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "zen-cli config updated.")
+	return nil
+}
+
+func printProfiles(out io.Writer, names []string) {
+	fmt.Fprintln(out, "zen-cli profiles:")
+	for _, name := range names {
+		fmt.Fprintf(out, "- %s\n", name)
+	}
+}
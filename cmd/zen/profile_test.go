@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"zen-cli/internal/zencli"
+)
+
+func writeTestConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+const profileTestConfig = `{
+  "allowedApps": ["Ghostty"],
+  "disallowedApps": [],
+  "profiles": {
+    "coding": {"allowedApps": ["Visual Studio Code"], "disallowedApps": ["Slack"]},
+    "writing": {"allowedApps": ["Obsidian"], "replaceDefaultAllowed": true}
+  }
+}`
+
+func TestLoadProfileFromConfig(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+
+	got, err := loadProfileFromConfig(path, "coding", true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := zencli.Options{
+		AllowedApps:    []string{"Visual Studio Code"},
+		DisallowedApps: []string{"Slack"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected options: got %+v want %+v", got, want)
+	}
+}
+
+func TestLoadProfileFromConfigDefault(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+
+	got, err := loadProfileFromConfig(path, defaultProfileName, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(got.AllowedApps, []string{"Ghostty"}) {
+		t.Fatalf("unexpected options: %+v", got)
+	}
+}
+
+func TestLoadProfileFromConfigUnknownProfile(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+
+	if _, err := loadProfileFromConfig(path, "meeting", true); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestLoadProfileFromConfigMissingFileNamedProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+
+	if _, err := loadProfileFromConfig(path, "coding", false); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestUpdateConfigFileKeepsProfiles(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+
+	err := updateConfigFile(path, func(cfg *configFile) error {
+		return cfg.setProfileOptions(defaultProfileName, zencli.Options{AllowedApps: []string{"Arc"}})
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	cfg, err := readConfigFile(path, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(cfg.AllowedApps, []string{"Arc"}) {
+		t.Fatalf("unexpected default profile: %+v", cfg.profileConfig)
+	}
+	if !reflect.DeepEqual(cfg.profileNames(), []string{"default", "coding", "writing"}) {
+		t.Fatalf("unexpected profiles: %v", cfg.profileNames())
+	}
+}
+
+func TestUpdateConfigFileUnknownProfile(t *testing.T) {
+	path := writeTestConfig(t, profileTestConfig)
+
+	err := updateConfigFile(path, func(cfg *configFile) error {
+		return cfg.setProfileOptions("meeting", zencli.Options{})
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCreateProfile(t *testing.T) {
+	var cfg configFile
+	if err := createProfile(&cfg, "meeting", profileConfig{}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !cfg.hasProfile("meeting") {
+		t.Fatal("expected profile to exist")
+	}
+	if err := createProfile(&cfg, "meeting", profileConfig{}); err == nil {
+		t.Fatal("expected duplicate error, got nil")
+	}
+}
+
+func TestCreateProfileRejectsReservedName(t *testing.T) {
+	var cfg configFile
+	if err := createProfile(&cfg, defaultProfileName, profileConfig{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if err := createProfile(&cfg, " padded ", profileConfig{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestDeleteProfile(t *testing.T) {
+	cfg := configFile{Profiles: map[string]profileConfig{"coding": {}}}
+
+	if err := deleteProfile(&cfg, "coding"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if cfg.Profiles != nil {
+		t.Fatalf("expected profiles to be cleared, got %v", cfg.Profiles)
+	}
+	if err := deleteProfile(&cfg, "coding"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if err := deleteProfile(&cfg, defaultProfileName); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCopyProfile(t *testing.T) {
+	cfg := configFile{profileConfig: profileConfig{AllowedApps: []string{"Ghostty"}}}
+
+	if err := copyProfile(&cfg, defaultProfileName, "coding"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Profiles["coding"].AllowedApps, []string{"Ghostty"}) {
+		t.Fatalf("unexpected copied profile: %+v", cfg.Profiles["coding"])
+	}
+}
+
+func TestParseProfileArgs(t *testing.T) {
+	parsed, err := parseProfileArgs([]string{"copy", "coding", "writing"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if parsed.command != commandProfile || parsed.action != profileActionCopy {
+		t.Fatalf("unexpected parse result: %+v", parsed)
+	}
+	if !reflect.DeepEqual(parsed.actionArgs, []string{"coding", "writing"}) {
+		t.Fatalf("unexpected action args: %v", parsed.actionArgs)
+	}
+}
+
+func TestParseProfileArgsErrors(t *testing.T) {
+	cases := [][]string{
+		nil,
+		{"rename", "a"},
+		{"create"},
+		{"copy", "a"},
+		{"list", "extra"},
+	}
+	for _, args := range cases {
+		if _, err := parseProfileArgs(args); err == nil {
+			t.Fatalf("expected error for %v, got nil", args)
+		}
+	}
+}
+
+func TestRunProfileCommandLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	var out bytes.Buffer
+
+	if err := runProfileCommand(&out, path, profileActionCreate, []string{"coding"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := runProfileCommand(&out, path, profileActionCopy, []string{"coding", "meeting"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	out.Reset()
+	if err := runProfileCommand(&out, path, profileActionList, nil); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := "zen-cli profiles:\n- default\n- coding\n- meeting\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+// maxSnapshots is how many snapshots snapshots.json keeps.
+const maxSnapshots = 20
+
+// snapshot is the set of apps one run or session start closed.
+type snapshot struct {
+	ID      int           `json:"id"`
+	Time    time.Time     `json:"time"`
+	Command string        `json:"command"`
+	Profile string        `json:"profile"`
+	Apps    []snapshotApp `json:"apps"`
+}
+
+type snapshotApp struct {
+	App      string `json:"app"`
+	BundleID string `json:"bundleId,omitempty"`
+}
+
+type snapshotFile struct {
+	Snapshots []snapshot `json:"snapshots"`
+}
+
+// UnmarshalJSON also accepts a plain app name, which is how session files
+// written by older versions list the closed apps.
+func (a *snapshotApp) UnmarshalJSON(raw []byte) error {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		*a = snapshotApp{App: name}
+		return nil
+	}
+	type plainApp snapshotApp
+	var app plainApp
+	if err := json.Unmarshal(raw, &app); err != nil {
+		return err
+	}
+	*a = snapshotApp(app)
+	return nil
+}
+
+func (s snapshot) appInfos() []zencli.AppInfo {
+	return appInfosOf(s.Apps)
+}
+
+func appInfosOf(apps []snapshotApp) []zencli.AppInfo {
+	infos := make([]zencli.AppInfo, 0, len(apps))
+	for _, app := range apps {
+		infos = append(infos, zencli.AppInfo{Name: app.App, BundleID: app.BundleID})
+	}
+	return infos
+}
+
+func snapshotAppsOf(infos []zencli.AppInfo) []snapshotApp {
+	apps := make([]snapshotApp, 0, len(infos))
+	for _, info := range infos {
+		apps = append(apps, snapshotApp{App: info.Name, BundleID: info.BundleID})
+	}
+	return apps
+}
+
+func snapshotAppNames(apps []snapshotApp) []string {
+	names := make([]string, 0, len(apps))
+	for _, app := range apps {
+		names = append(names, app.App)
+	}
+	return names
+}
+
+func defaultSnapshotPath() (string, error) {
+	historyPath, err := defaultHistoryPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(historyPath), "snapshots.json"), nil
+}
+
+// newSnapshot returns the apps of results that were closed. A watch can
+// close the same app several times; it is listed once.
+func newSnapshot(command string, profile string, results []zencli.QuitResult) snapshot {
+	var closed []snapshotApp
+	for _, result := range results {
+		if result.Err == nil {
+			closed = append(closed, snapshotApp{App: result.App, BundleID: result.BundleID})
+		}
+	}
+	return snapshot{Time: time.Now(), Command: command, Profile: reportProfile(profile), Apps: mergeSessionApps(nil, closed)}
+}
+
+// recordSnapshot saves the apps a run closed so zen restore can reopen them.
+// Like history it is best effort, and runs that closed nothing are skipped
+// so --last keeps pointing at something worth restoring.
+func recordSnapshot(snap snapshot) {
+	if len(snap.Apps) == 0 {
+		return
+	}
+	path, err := defaultSnapshotPath()
+	if err == nil {
+		_, err = appendSnapshot(path, snap, maxSnapshots)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "zen-cli: failed to record snapshot: %v\n", err)
+	}
+}
+
+// appendSnapshot assigns snap the next ID, saves it and drops the oldest
+// snapshots beyond keep. Like config updates it holds the file lock and
+// replaces the file atomically, so concurrent runs do not lose snapshots or
+// reuse IDs.
+func appendSnapshot(path string, snap snapshot, keep int) (snapshot, error) {
+	err := withConfigLock(path, func() error {
+		snapshots, err := readSnapshots(path)
+		if err != nil {
+			return err
+		}
+
+		snap.ID = 1
+		if len(snapshots) > 0 {
+			snap.ID = snapshots[len(snapshots)-1].ID + 1
+		}
+		snapshots = append(snapshots, snap)
+		if len(snapshots) > keep {
+			snapshots = snapshots[len(snapshots)-keep:]
+		}
+
+		body, err := json.MarshalIndent(snapshotFile{Snapshots: snapshots}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal snapshots: %w", err)
+		}
+		return writeConfigBytes(path, append(body, '\n'))
+	})
+	if err != nil {
+		return snapshot{}, err
+	}
+	return snap, nil
+}
+
+// readSnapshots returns the saved snapshots, oldest first.
+func readSnapshots(path string) ([]snapshot, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshot file %s: %w", path, err)
+	}
+
+	var file snapshotFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot file %s: %w", path, err)
+	}
+	return file.Snapshots, nil
+}
+
+// findSnapshot returns the snapshot with id, or the latest one when id is 0.
+func findSnapshot(snapshots []snapshot, id int) (snapshot, error) {
+	if len(snapshots) == 0 {
+		return snapshot{}, errors.New("no snapshot to restore: zen has not closed any apps yet")
+	}
+	if id == 0 {
+		return snapshots[len(snapshots)-1], nil
+	}
+	for _, snap := range snapshots {
+		if snap.ID == id {
+			return snap, nil
+		}
+	}
+	return snapshot{}, fmt.Errorf("snapshot %d not found", id)
+}
+
+func parseRestoreArgs(args []string) (parsedArgs, error) {
+	fs := flag.NewFlagSet("zen restore", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	last := fs.Bool("last", false, "restore the most recent snapshot (default)")
+	id := fs.Int("id", 0, "restore the snapshot with this ID")
+	list := fs.Bool("list", false, "list the saved snapshots")
+	dryRun := fs.Bool("dry-run", false, "show which apps would be reopened")
+	output := fs.String("output", string(outputText), "output format: text, json or ndjson")
+	if err := fs.Parse(args); err != nil {
+		return parsedArgs{}, err
+	}
+	if fs.NArg() != 0 {
+		return parsedArgs{}, errors.New("zen restore does not accept extra arguments")
+	}
+
+	seen := make(map[string]struct{})
+	fs.Visit(func(f *flag.Flag) {
+		seen[f.Name] = struct{}{}
+	})
+	if *last && hasFlag(seen, "id") {
+		return parsedArgs{}, errors.New("--last and --id cannot be used together")
+	}
+	if hasFlag(seen, "id") && *id < 1 {
+		return parsedArgs{}, errors.New("--id must be at least 1")
+	}
+	if *list && (*last || hasFlag(seen, "id") || *dryRun) {
+		return parsedArgs{}, errors.New("--list cannot be combined with other restore flags")
+	}
+
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return parsedArgs{}, err
+	}
+
+	action := "restore"
+	if *list {
+		action = "list"
+	}
+	return parsedArgs{command: commandRestore, action: action, restoreID: *id, dryRun: *dryRun, output: format}, nil
+}
+
+func runRestoreCommand(out io.Writer, executor zencli.Executor, parsed parsedArgs) error {
+	path, err := defaultSnapshotPath()
+	if err != nil {
+		return err
+	}
+	snapshots, err := readSnapshots(path)
+	if err != nil {
+		return err
+	}
+	if parsed.action == "list" {
+		return printSnapshots(out, parsed.output, snapshots)
+	}
+
+	snap, err := findSnapshot(snapshots, parsed.restoreID)
+	if err != nil {
+		return err
+	}
+	results, restoreErr := zencli.RestoreApps(executor, snap.appInfos(), parsed.dryRun)
+	if results == nil && restoreErr != nil {
+		return restoreErr
+	}
+	if err := printRestoreReport(out, parsed.output, snap, parsed.dryRun, results); err != nil {
+		return err
+	}
+	if restoreErr != nil {
+		return errors.New("some apps could not be reopened")
+	}
+	return nil
+}
+
+type restoreReport struct {
+	Command  string          `json:"command"`
+	Snapshot int             `json:"snapshot"`
+	DryRun   bool            `json:"dryRun"`
+	Results  []restoreResult `json:"results"`
+}
+
+type restoreResult struct {
+	App      string `json:"app"`
+	BundleID string `json:"bundleId,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+func newRestoreResult(result zencli.RestoreResult) restoreResult {
+	converted := restoreResult{App: result.App, BundleID: result.BundleID, Status: string(result.Outcome)}
+	if result.Err != nil {
+		converted.Error = result.Err.Error()
+	}
+	return converted
+}
+
+func printRestoreReport(out io.Writer, format outputFormat, snap snapshot, dryRun bool, results []zencli.RestoreResult) error {
+	converted := make([]restoreResult, 0, len(results))
+	for _, result := range results {
+		converted = append(converted, newRestoreResult(result))
+	}
+
+	switch format {
+	case outputJSON:
+		return writeJSON(out, restoreReport{Command: string(commandRestore), Snapshot: snap.ID, DryRun: dryRun, Results: converted})
+	case outputNDJSON:
+		enc := json.NewEncoder(out)
+		for _, result := range converted {
+			if err := enc.Encode(result); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+		return nil
+	}
+
+	fmt.Fprintf(out, "zen-cli snapshot %d (%s, %s):\n", snap.ID, snap.Command, snap.Time.Local().Format("2006-01-02 15:04"))
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "APP\tSTATUS\tERROR")
+	for _, result := range converted {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.App, result.Status, result.Error)
+	}
+	return tw.Flush()
+}
+
+func printSnapshots(out io.Writer, format outputFormat, snapshots []snapshot) error {
+	switch format {
+	case outputJSON:
+		if snapshots == nil {
+			snapshots = []snapshot{}
+		}
+		return writeJSON(out, snapshotFile{Snapshots: snapshots})
+	case outputNDJSON:
+		enc := json.NewEncoder(out)
+		for _, snap := range snapshots {
+			if err := enc.Encode(snap); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Fprintln(out, "zen-cli: no snapshots.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTIME\tCOMMAND\tPROFILE\tAPPS")
+	for _, snap := range snapshots {
+		apps := make([]string, 0, len(snap.Apps))
+		for _, app := range snap.Apps {
+			apps = append(apps, app.App)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", snap.ID, snap.Time.Local().Format("2006-01-02 15:04"), snap.Command, snap.Profile, strings.Join(apps, ", "))
+	}
+	return tw.Flush()
+}
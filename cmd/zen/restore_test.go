@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+func TestParseRestoreArgs(t *testing.T) {
+	got, err := parseRestoreArgs([]string{"--id", "3", "--dry-run", "--output", "json"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := parsedArgs{command: commandRestore, action: "restore", restoreID: 3, dryRun: true, output: outputJSON}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args: got %+v want %+v", got, want)
+	}
+
+	got, err = parseRestoreArgs([]string{"--list"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got.action != "list" {
+		t.Fatalf("unexpected action: got %q want %q", got.action, "list")
+	}
+}
+
+func TestParseRestoreArgsErrors(t *testing.T) {
+	tests := [][]string{
+		{"--last", "--id", "2"},
+		{"--id", "0"},
+		{"--list", "--dry-run"},
+		{"Slack"},
+	}
+	for _, args := range tests {
+		if _, err := parseRestoreArgs(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
+
+func TestNewSnapshotKeepsClosedApps(t *testing.T) {
+	results := []zencli.QuitResult{
+		{App: "Safari", BundleID: "com.apple.Safari"},
+		{App: "Slack", Err: errors.New("still running")},
+	}
+
+	got := newSnapshot(historyRun, "", results)
+	want := []snapshotApp{{App: "Safari", BundleID: "com.apple.Safari"}}
+	if !reflect.DeepEqual(got.Apps, want) {
+		t.Fatalf("unexpected apps: got %+v want %+v", got.Apps, want)
+	}
+	if got.Profile != defaultProfileName {
+		t.Fatalf("unexpected profile: got %q want %q", got.Profile, defaultProfileName)
+	}
+}
+
+func TestNewSnapshotListsAppsOnce(t *testing.T) {
+	results := []zencli.QuitResult{
+		{App: "Slack", BundleID: "com.tinyspeck.slackmacgap"},
+		{App: "Safari"},
+		{App: "Slack", BundleID: "com.tinyspeck.slackmacgap"},
+	}
+
+	got := newSnapshot(historyWatch, "", results)
+	want := []snapshotApp{{App: "Slack", BundleID: "com.tinyspeck.slackmacgap"}, {App: "Safari"}}
+	if !reflect.DeepEqual(got.Apps, want) {
+		t.Fatalf("unexpected apps: got %+v want %+v", got.Apps, want)
+	}
+}
+
+func TestAppendSnapshotAssignsIDsAndTrims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "snapshots.json")
+	for _, app := range []string{"Safari", "Slack", "Music"} {
+		snap := snapshot{Command: historyRun, Profile: defaultProfileName, Apps: []snapshotApp{{App: app}}}
+		if _, err := appendSnapshot(path, snap, 2); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	snapshots, err := readSnapshots(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	var ids []int
+	for _, snap := range snapshots {
+		ids = append(ids, snap.ID)
+	}
+	if !reflect.DeepEqual(ids, []int{2, 3}) {
+		t.Fatalf("unexpected ids: got %v want %v", ids, []int{2, 3})
+	}
+}
+
+func TestFindSnapshot(t *testing.T) {
+	snapshots := []snapshot{{ID: 4}, {ID: 5}}
+
+	if got, err := findSnapshot(snapshots, 0); err != nil || got.ID != 5 {
+		t.Fatalf("unexpected latest snapshot: got %+v, %v", got, err)
+	}
+	if got, err := findSnapshot(snapshots, 4); err != nil || got.ID != 4 {
+		t.Fatalf("unexpected snapshot: got %+v, %v", got, err)
+	}
+	if _, err := findSnapshot(snapshots, 9); err == nil {
+		t.Fatal("expected missing snapshot error")
+	}
+	if _, err := findSnapshot(nil, 0); err == nil {
+		t.Fatal("expected empty snapshot error")
+	}
+}
+
+func TestPrintRestoreReportText(t *testing.T) {
+	snap := snapshot{ID: 2, Command: historyRun, Time: time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)}
+	results := []zencli.RestoreResult{
+		{App: "Safari", Outcome: zencli.RestoreLaunched},
+		{App: "Slack", Outcome: zencli.RestoreAlreadyRunning},
+		{App: "Music", Outcome: zencli.RestoreFailed, Err: errors.New("not found")},
+	}
+
+	var out bytes.Buffer
+	if err := printRestoreReport(&out, outputText, snap, false, results); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := "zen-cli snapshot 2 (run, 2026-03-02 09:00):\n" +
+		"APP     STATUS           ERROR\n" +
+		"Safari  launched         \n" +
+		"Slack   already-running  \n" +
+		"Music   failed           not found\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestPrintRestoreReportJSON(t *testing.T) {
+	snap := snapshot{ID: 2}
+	results := []zencli.RestoreResult{{App: "Slack", BundleID: "com.tinyspeck.slackmacgap", Outcome: zencli.RestoreWouldLaunch}}
+
+	var out bytes.Buffer
+	if err := printRestoreReport(&out, outputJSON, snap, true, results); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := `{
+  "command": "restore",
+  "snapshot": 2,
+  "dryRun": true,
+  "results": [
+    {
+      "app": "Slack",
+      "bundleId": "com.tinyspeck.slackmacgap",
+      "status": "would-launch"
+    }
+  ]
+}
+`
+	if out.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+const (
+	scheduleActionList  = "list"
+	scheduleActionNext  = "next"
+	scheduleActionCheck = "check"
+)
+
+// scheduleConfig is one entry of the "schedules" config section. It is
+// either a time range (start, end and optional days) or a cron expression
+// with a duration; both are bound to a profile.
+type scheduleConfig struct {
+	Name     string   `json:"name"`
+	Profile  string   `json:"profile,omitempty"`
+	Days     []string `json:"days,omitempty"`
+	Start    string   `json:"start,omitempty"`
+	End      string   `json:"end,omitempty"`
+	Cron     string   `json:"cron,omitempty"`
+	Duration string   `json:"duration,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+// when describes the rule for zen schedule list.
+func (s scheduleConfig) when() string {
+	if strings.TrimSpace(s.Cron) != "" {
+		return fmt.Sprintf("cron %q for %s", s.Cron, s.Duration)
+	}
+	days := "daily"
+	if len(s.Days) > 0 {
+		days = strings.Join(s.Days, ",")
+	}
+	return fmt.Sprintf("%s %s-%s", days, s.Start, s.End)
+}
+
+// compile checks the rule against cfg and compiles it. Rules without a
+// timezone use local.
+func (s scheduleConfig) compile(cfg configFile, local *time.Location) (zencli.ScheduleRule, error) {
+	spec := zencli.ScheduleRuleSpec{
+		Name:     s.Name,
+		Profile:  s.Profile,
+		Days:     s.Days,
+		Start:    s.Start,
+		End:      s.End,
+		Cron:     s.Cron,
+		Location: local,
+	}
+	if strings.TrimSpace(s.Duration) != "" {
+		duration, err := time.ParseDuration(strings.TrimSpace(s.Duration))
+		if err != nil {
+			return zencli.ScheduleRule{}, fmt.Errorf("invalid duration %q: %w", s.Duration, err)
+		}
+		spec.Duration = duration
+	}
+	if tz := strings.TrimSpace(s.Timezone); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return zencli.ScheduleRule{}, fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+		spec.Location = loc
+	}
+	if !cfg.hasProfile(s.Profile) {
+		return zencli.ScheduleRule{}, fmt.Errorf("profile %q not found", strings.TrimSpace(s.Profile))
+	}
+	return zencli.CompileScheduleRule(spec)
+}
+
+// compileSchedules compiles the schedules of cfg in precedence order.
+func compileSchedules(cfg configFile, local *time.Location) ([]zencli.ScheduleRule, error) {
+	rules := make([]zencli.ScheduleRule, 0, len(cfg.Schedules))
+	seen := make(map[string]struct{}, len(cfg.Schedules))
+	for i, schedule := range cfg.Schedules {
+		rule, err := schedule.compile(cfg, local)
+		if err != nil {
+			return nil, fmt.Errorf("schedules[%d]: %w", i, err)
+		}
+		key := strings.ToLower(rule.Name)
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("schedules[%d]: duplicate name %q", i, rule.Name)
+		}
+		seen[key] = struct{}{}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseScheduleArgs(args []string) (parsedArgs, error) {
+	if len(args) == 0 {
+		return parsedArgs{}, errors.New("zen schedule requires an action: list, next or check")
+	}
+
+	action := args[0]
+	switch action {
+	case scheduleActionList, scheduleActionNext, scheduleActionCheck:
+	default:
+		return parsedArgs{}, fmt.Errorf("unknown schedule action %q", action)
+	}
+
+	fs := flag.NewFlagSet("zen schedule "+action, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	at := fs.String("at", "", "evaluate at this time instead of now")
+	if err := fs.Parse(args[1:]); err != nil {
+		return parsedArgs{}, err
+	}
+	if fs.NArg() != 0 {
+		return parsedArgs{}, fmt.Errorf("zen schedule %s does not accept extra arguments", action)
+	}
+	if action == scheduleActionList && *at != "" {
+		return parsedArgs{}, errors.New("--at is only used by next and check")
+	}
+	if _, err := parseScheduleTime(*at, time.Now()); err != nil {
+		return parsedArgs{}, err
+	}
+
+	return parsedArgs{command: commandSchedule, action: action, scheduleAt: strings.TrimSpace(*at)}, nil
+}
+
+// parseScheduleTime reads --at: RFC 3339, "YYYY-MM-DD HH:MM" or "HH:MM"
+// today, the last two in the time zone of now. An empty value is now.
+func parseScheduleTime(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, raw, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if clock, err := time.ParseInLocation("15:04", raw, now.Location()); err == nil {
+		return time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --at %q: expected HH:MM, YYYY-MM-DD HH:MM or RFC 3339", raw)
+}
+
+func runScheduleCommand(out io.Writer, configPath string, parsed parsedArgs, now time.Time) error {
+	cfg, err := readConfigFile(configPath, false)
+	if err != nil {
+		return err
+	}
+	rules, err := compileSchedules(cfg, now.Location())
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+	at, err := parseScheduleTime(parsed.scheduleAt, now)
+	if err != nil {
+		return err
+	}
+
+	switch parsed.action {
+	case scheduleActionList:
+		return printSchedules(out, cfg.Schedules)
+	case scheduleActionNext:
+		window, ok := zencli.NextWindow(rules, at)
+		if !ok {
+			fmt.Fprintln(out, "zen-cli: no upcoming schedule.")
+			return nil
+		}
+		fmt.Fprintf(out, "zen-cli next schedule: %s (profile %s) from %s to %s.\n",
+			window.Rule, reportProfile(window.Profile), formatScheduleTime(window.Start), formatWindowEnd(window))
+		return nil
+	case scheduleActionCheck:
+		window, ok := zencli.ActiveWindow(rules, at)
+		if !ok {
+			fmt.Fprintf(out, "zen-cli schedule at %s: no rule is active.\n", formatScheduleTime(at))
+			return nil
+		}
+		opts, err := loadLayeredOptions(configPath, window.Profile, false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "zen-cli schedule at %s: %s (profile %s) until %s.\n",
+			formatScheduleTime(at.In(window.Start.Location())), window.Rule, reportProfile(window.Profile), formatWindowEnd(window))
+		printAllowedApps(out, zencli.EffectiveAllowedApps(opts))
+		return nil
+	}
+	return fmt.Errorf("unknown schedule action %q", parsed.action)
+}
+
+func formatScheduleTime(t time.Time) string {
+	return t.Format("Mon 2006-01-02 15:04 MST")
+}
+
+// formatWindowEnd leaves out the date when the window ends on the day it
+// starts.
+func formatWindowEnd(window zencli.ScheduleWindow) string {
+	if window.End.YearDay() == window.Start.YearDay() && window.End.Year() == window.Start.Year() {
+		return window.End.Format("15:04")
+	}
+	return formatScheduleTime(window.End)
+}
+
+func printSchedules(out io.Writer, schedules []scheduleConfig) error {
+	if len(schedules) == 0 {
+		fmt.Fprintln(out, "zen-cli: no schedules configured.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tPROFILE\tWHEN\tTIMEZONE")
+	for _, schedule := range schedules {
+		tz := schedule.Timezone
+		if tz == "" {
+			tz = "local"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", schedule.Name, reportProfile(schedule.Profile), schedule.when(), tz)
+	}
+	return tw.Flush()
+}
+
+func parseDaemonArgs(args []string) (parsedArgs, error) {
+	fs := flag.NewFlagSet("zen daemon", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	interval := fs.Duration("interval", defaultWatchInterval, "time between two scans")
+	if err := fs.Parse(args); err != nil {
+		return parsedArgs{}, err
+	}
+	if fs.NArg() != 0 {
+		return parsedArgs{}, errors.New("zen daemon does not accept extra arguments")
+	}
+	if *interval < zencli.MinWatchInterval {
+		return parsedArgs{}, fmt.Errorf("--interval must be at least %s", zencli.MinWatchInterval)
+	}
+	return parsedArgs{command: commandDaemon, watchInterval: *interval}, nil
+}
+
+// schedulePlan is what the daemon enforces: the rules and the layered
+// options of every profile they use.
+type schedulePlan struct {
+	rules   []zencli.ScheduleRule
+	options map[string]zencli.Options
+}
+
+func loadSchedulePlan(configPath string) (schedulePlan, error) {
+	cfg, err := readConfigFile(configPath, false)
+	if err != nil {
+		return schedulePlan{}, err
+	}
+	rules, err := compileSchedules(cfg, time.Local)
+	if err != nil {
+		return schedulePlan{}, fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+
+	plan := schedulePlan{rules: rules, options: make(map[string]zencli.Options)}
+	for _, rule := range rules {
+		if _, ok := plan.options[rule.Profile]; ok {
+			continue
+		}
+		opts, err := loadLayeredOptions(configPath, rule.Profile, false)
+		if err != nil {
+			return schedulePlan{}, err
+		}
+		if err := validateOptions(opts); err != nil {
+			return schedulePlan{}, fmt.Errorf("profile %s: %w", reportProfile(rule.Profile), err)
+		}
+		plan.options[rule.Profile] = opts
+	}
+	return plan, nil
+}
+
+// scheduleDaemon applies the active schedule rule on every scan of a watch
+// loop and records each window in the history when it ends.
+type scheduleDaemon struct {
+	out        io.Writer
+	log        io.Writer
+	configPath string
+	plan       schedulePlan
+	reloads    <-chan configReload
+
+	active   bool
+	window   zencli.ScheduleWindow
+	started  time.Time
+	attempts []zencli.QuitResult
+}
+
+// options returns the options of the rule active at now, and false when no
+// rule is active so the scan is skipped.
+func (d *scheduleDaemon) options(now time.Time) (zencli.Options, bool) {
+	d.applyReloads()
+
+	window, ok := zencli.ActiveWindow(d.plan.rules, now)
+	if ok != d.active || window.Rule != d.window.Rule || !window.Start.Equal(d.window.Start) {
+		d.finish(now)
+		if ok {
+			fmt.Fprintf(d.out, "[%s] schedule %s started (profile %s) until %s\n",
+				now.Format("15:04:05"), window.Rule, reportProfile(window.Profile), formatWindowEnd(window))
+			d.started = now
+		}
+		d.active, d.window = ok, window
+	}
+	if !ok {
+		return zencli.Options{}, false
+	}
+	return watchOptions(d.plan.options[window.Profile]), true
+}
+
+// applyReloads picks up config changes. A config that loads but whose
+// schedules do not keeps the previous plan.
+func (d *scheduleDaemon) applyReloads() {
+	for {
+		select {
+		case reload, ok := <-d.reloads:
+			if !ok {
+				d.reloads = nil
+				return
+			}
+			if reload.Err != nil {
+				continue
+			}
+			plan, err := loadSchedulePlan(d.configPath)
+			if err != nil {
+				fmt.Fprintf(d.log, "zen-cli: schedule change ignored, keeping the previous schedules: %v\n", err)
+				continue
+			}
+			d.plan = plan
+		default:
+			return
+		}
+	}
+}
+
+func (d *scheduleDaemon) onQuit(event zencli.WatchEvent) {
+	d.attempts = append(d.attempts, event.Result)
+	printWatchEvent(d.out, event)
+}
+
+// finish ends the current window, if any.
+func (d *scheduleDaemon) finish(now time.Time) {
+	if !d.active {
+		return
+	}
+	fmt.Fprintf(d.out, "[%s] schedule %s ended\n", now.Format("15:04:05"), d.window.Rule)
+
+	opts := d.plan.options[d.window.Profile]
+	entry := newRunHistoryEntry(historySchedule, d.window.Profile, zencli.EffectiveAllowedApps(opts), d.attempts)
+	entry.Time = d.started
+	entry.DurationSeconds = int64(now.Sub(d.started).Seconds())
+	recordHistory(entry)
+	snap := newSnapshot(historySchedule, d.window.Profile, d.attempts)
+	snap.Time = d.started
+	recordSnapshot(snap)
+
+	d.active, d.window, d.attempts = false, zencli.ScheduleWindow{}, nil
+}
+
+func runDaemonCommand(out io.Writer, executor zencli.Executor, configPath string, parsed parsedArgs) error {
+	plan, err := loadSchedulePlan(configPath)
+	if err != nil {
+		return err
+	}
+	config, err := newConfigWatcher(configPath, "", os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	daemon := &scheduleDaemon{
+		out:        out,
+		log:        os.Stderr,
+		configPath: configPath,
+		plan:       plan,
+		reloads:    config.Subscribe(),
+	}
+	go config.Run(ctx)
+
+	if len(plan.rules) == 0 {
+		fmt.Fprintf(out, "zen-cli daemon: no schedules in %s yet; waiting for them.\n", configPath)
+	}
+	fmt.Fprintf(out, "zen-cli daemon checking schedules every %s (Ctrl-C to stop).\n", parsed.watchInterval)
+
+	err = zencli.Watch(ctx, executor, zencli.Options{}, zencli.WatchOptions{
+		Interval: parsed.watchInterval,
+		OnQuit:   daemon.onQuit,
+		OnScanError: func(event zencli.WatchEvent) {
+			printWatchEvent(out, event)
+		},
+		Options: daemon.options,
+	})
+	daemon.finish(time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "zen-cli daemon stopped.")
+	return nil
+}
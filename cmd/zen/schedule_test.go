@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+const scheduleTestConfig = `{
+  "allowedApps": ["Ghostty"],
+  "profiles": {
+    "coding": {"allowedApps": ["Visual Studio Code"]},
+    "writing": {"allowedApps": ["Obsidian"]}
+  },
+  "schedules": [
+    {"name": "deep-work", "profile": "coding", "days": ["weekdays"], "start": "09:00", "end": "12:00", "timezone": "UTC"},
+    {"name": "writing", "profile": "writing", "cron": "0 14 * * 1-5", "duration": "2h", "timezone": "UTC"}
+  ]
+}`
+
+// scheduleTestNow is Monday 2026-03-02 08:00 UTC.
+var scheduleTestNow = time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+func TestCompileSchedules(t *testing.T) {
+	cfg, _, err := parseConfig([]byte(scheduleTestConfig), true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	rules, err := compileSchedules(cfg, time.Local)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(rules) != 2 || rules[0].Name != "deep-work" || rules[1].Profile != "writing" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if rules[0].Location() != time.UTC {
+		t.Fatalf("unexpected location: %v", rules[0].Location())
+	}
+}
+
+func TestCompileSchedulesErrors(t *testing.T) {
+	cases := map[string]string{
+		`{"schedules": [{"name": "a", "profile": "missing", "start": "09:00", "end": "10:00"}]}`:                                 `profile "missing" not found`,
+		`{"schedules": [{"name": "a", "start": "09:00", "end": "10:00", "timezone": "Mars/Base"}]}`:                              "invalid timezone",
+		`{"schedules": [{"name": "a", "cron": "0 9 * * *", "duration": "soon"}]}`:                                                "invalid duration",
+		`{"schedules": [{"name": "a", "start": "09:00", "end": "10:00"}, {"name": "A", "cron": "0 9 * * *", "duration": "1h"}]}`: `schedules[1]: duplicate name "A"`,
+	}
+	for raw, want := range cases {
+		cfg, _, err := parseConfig([]byte(raw), true)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if _, err := compileSchedules(cfg, time.UTC); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q error for %s, got %v", want, raw, err)
+		}
+		if err := cfg.validate(); err == nil {
+			t.Fatalf("expected validation error for %s", raw)
+		}
+	}
+}
+
+func TestParseScheduleArgs(t *testing.T) {
+	got, err := parseScheduleArgs([]string{"check", "--at", "10:30"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := parsedArgs{command: commandSchedule, action: scheduleActionCheck, scheduleAt: "10:30"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args: got %+v want %+v", got, want)
+	}
+
+	invalid := [][]string{nil, {"run"}, {"list", "--at", "10:00"}, {"next", "--at", "noon"}, {"check", "extra"}}
+	for _, args := range invalid {
+		if _, err := parseScheduleArgs(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
+
+func TestParseScheduleTime(t *testing.T) {
+	cases := map[string]time.Time{
+		"":                          scheduleTestNow,
+		"10:30":                     time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC),
+		"2026-03-04 14:15":          time.Date(2026, 3, 4, 14, 15, 0, 0, time.UTC),
+		"2026-03-04T14:15:00+09:00": time.Date(2026, 3, 4, 5, 15, 0, 0, time.UTC),
+	}
+	for raw, want := range cases {
+		got, err := parseScheduleTime(raw, scheduleTestNow)
+		if err != nil {
+			t.Fatalf("expected nil error for %q, got %v", raw, err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("unexpected time for %q: got %s want %s", raw, got, want)
+		}
+	}
+}
+
+func TestRunScheduleCommand(t *testing.T) {
+	t.Setenv(configDirsEnv, t.TempDir())
+	path := writeTestConfig(t, scheduleTestConfig)
+	run := func(action, at string) string {
+		t.Helper()
+		var out bytes.Buffer
+		parsed := parsedArgs{command: commandSchedule, action: action, scheduleAt: at}
+		if err := runScheduleCommand(&out, path, parsed, scheduleTestNow); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		return out.String()
+	}
+
+	list := run(scheduleActionList, "")
+	for _, part := range []string{"NAME", "deep-work", "weekdays 09:00-12:00", `cron "0 14 * * 1-5" for 2h`, "UTC"} {
+		if !strings.Contains(list, part) {
+			t.Fatalf("expected %q in list, got %q", part, list)
+		}
+	}
+
+	if got, want := run(scheduleActionNext, ""), "zen-cli next schedule: deep-work (profile coding) from Mon 2026-03-02 09:00 UTC to 12:00.\n"; got != want {
+		t.Fatalf("unexpected next: got %q want %q", got, want)
+	}
+	if got, want := run(scheduleActionNext, "12:00"), "zen-cli next schedule: writing (profile writing) from Mon 2026-03-02 14:00 UTC to 16:00.\n"; got != want {
+		t.Fatalf("unexpected next: got %q want %q", got, want)
+	}
+
+	check := run(scheduleActionCheck, "15:00")
+	if !strings.HasPrefix(check, "zen-cli schedule at Mon 2026-03-02 15:00 UTC: writing (profile writing) until 16:00.\n") || !strings.Contains(check, "- Obsidian") {
+		t.Fatalf("unexpected check: %q", check)
+	}
+	if got, want := run(scheduleActionCheck, "13:00"), "zen-cli schedule at Mon 2026-03-02 13:00 UTC: no rule is active.\n"; got != want {
+		t.Fatalf("unexpected check: got %q want %q", got, want)
+	}
+}
+
+func TestScheduleDaemonAppliesActiveRule(t *testing.T) {
+	t.Setenv(configDirsEnv, t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := writeTestConfig(t, scheduleTestConfig)
+	plan, err := loadSchedulePlan(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	daemon := &scheduleDaemon{out: &out, log: &bytes.Buffer{}, configPath: path, plan: plan}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+	}
+
+	if _, ok := daemon.options(at(8, 59)); ok {
+		t.Fatal("expected no rule before 09:00")
+	}
+	opts, ok := daemon.options(at(9, 0))
+	if !ok || !reflect.DeepEqual(opts.AllowedApps, []string{"Visual Studio Code"}) {
+		t.Fatalf("unexpected options at 09:00: %+v %v", opts, ok)
+	}
+	if opts.Escalation.GracePeriod != zencli.DefaultGracePeriod {
+		t.Fatalf("unexpected grace period: %s", opts.Escalation.GracePeriod)
+	}
+	daemon.onQuit(zencli.WatchEvent{Time: at(9, 0), App: "Slack", Result: zencli.QuitResult{App: "Slack"}})
+	if _, ok := daemon.options(at(12, 0)); ok {
+		t.Fatal("expected no rule at 12:00")
+	}
+	opts, ok = daemon.options(at(14, 0))
+	if !ok || !reflect.DeepEqual(opts.AllowedApps, []string{"Obsidian"}) {
+		t.Fatalf("unexpected options at 14:00: %+v %v", opts, ok)
+	}
+
+	want := "[09:00:00] schedule deep-work started (profile coding) until 12:00\n" +
+		"[09:00:00] closed Slack\n" +
+		"[12:00:00] schedule deep-work ended\n" +
+		"[14:00:00] schedule writing started (profile writing) until 16:00\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+
+	historyPath, err := defaultHistoryPath()
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	entries, err := readHistory(historyPath)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(entries) != 1 || entries[0].Command != historySchedule || entries[0].DurationSeconds != 3*60*60 || !reflect.DeepEqual(entries[0].Targets, []string{"Slack"}) {
+		t.Fatalf("unexpected history: %+v", entries)
+	}
+}
+
+func TestScheduleDaemonReloadsSchedules(t *testing.T) {
+	t.Setenv(configDirsEnv, t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := writeTestConfig(t, scheduleTestConfig)
+	plan, err := loadSchedulePlan(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	reloads := make(chan configReload, 1)
+	var log bytes.Buffer
+	daemon := &scheduleDaemon{out: &bytes.Buffer{}, log: &log, configPath: path, plan: plan, reloads: reloads}
+
+	// A broken schedule keeps the previous plan.
+	body := strings.Replace(scheduleTestConfig, `"start": "09:00"`, `"start": "9am"`, 1)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	reloads <- configReload{}
+	if _, ok := daemon.options(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)); !ok {
+		t.Fatal("expected the previous schedules to stay in effect")
+	}
+	if !strings.Contains(log.String(), "schedule change ignored") {
+		t.Fatalf("expected the rejection to be logged, got %q", log.String())
+	}
+
+	body = strings.Replace(scheduleTestConfig, `"start": "09:00"`, `"start": "10:30"`, 1)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	reloads <- configReload{}
+	if _, ok := daemon.options(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("expected the reloaded schedule to start at 10:30")
+	}
+}
+
+func TestParseDaemonArgs(t *testing.T) {
+	got, err := parseDaemonArgs([]string{"--interval", "30s"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := (parsedArgs{command: commandDaemon, watchInterval: 30 * time.Second}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args: got %+v want %+v", got, want)
+	}
+	for _, args := range [][]string{{"--interval", "10ms"}, {"now"}} {
+		if _, err := parseDaemonArgs(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
+
+func TestLoadSchedulePlanWithoutConfig(t *testing.T) {
+	t.Setenv(configDirsEnv, t.TempDir())
+	plan, err := loadSchedulePlan(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(plan.rules) != 0 {
+		t.Fatalf("expected no rules, got %+v", plan.rules)
+	}
+}
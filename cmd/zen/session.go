@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+const (
+	sessionActionStart  = "start"
+	sessionActionEnd    = "end"
+	sessionActionStatus = "status"
+	// sessionActionWait is run in the background by start --detach to end
+	// the session on time. It is not meant to be run by hand.
+	sessionActionWait = "wait"
+)
+
+const defaultSessionDuration = 50 * time.Minute
+
+// sessionWaitPoll bounds how long the background waiter sleeps between
+// checks. Timers do not advance while the machine sleeps, so it rereads the
+// wall clock instead of sleeping until the end in one go.
+const sessionWaitPoll = 30 * time.Second
+
+// sessionState is persisted next to the config file so a session outlives
+// the terminal that started it.
+type sessionState struct {
+	StartedAt time.Time `json:"startedAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	Profile   string    `json:"profile,omitempty"`
+	// ClosedApps keeps bundle IDs so apps are relaunched like zen restore
+	// does.
+	ClosedApps []snapshotApp `json:"closedApps"`
+}
+
+func (s sessionState) remaining(now time.Time) time.Duration {
+	if left := s.EndsAt.Sub(now); left > 0 {
+		return left
+	}
+	return 0
+}
+
+func (s sessionState) expired(now time.Time) bool {
+	return !now.Before(s.EndsAt)
+}
+
+func sessionStatePath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "session.json")
+}
+
+func loadSessionState(path string) (sessionState, bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return sessionState{}, false, nil
+		}
+		return sessionState{}, false, fmt.Errorf("failed to read session file %s: %w", path, err)
+	}
+
+	var state sessionState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return sessionState{}, false, fmt.Errorf("failed to parse session file %s: %w", path, err)
+	}
+	return state, true, nil
+}
+
+func saveSessionState(path string, state sessionState) error {
+	body, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	body = append(body, '\n')
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create session directory %s: %w", dir, err)
+	}
+	if err := os.WriteFile(path, body, 0o600); err != nil {
+		return fmt.Errorf("failed to write session file %s: %w", path, err)
+	}
+	return nil
+}
+
+func clearSessionState(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove session file %s: %w", path, err)
+	}
+	return nil
+}
+
+func parseSessionArgs(args []string) (parsedArgs, error) {
+	if len(args) == 0 {
+		return parsedArgs{}, errors.New("zen session requires an action: start, end or status")
+	}
+
+	action := args[0]
+	switch action {
+	case sessionActionStart:
+		fs := flag.NewFlagSet("zen session start", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		duration := fs.Duration("duration", defaultSessionDuration, "length of the focus session")
+		profile := fs.String("profile", "", "named profile from the config file")
+		detach := fs.Bool("detach", false, "return immediately instead of waiting for the session to end")
+		if err := fs.Parse(args[1:]); err != nil {
+			return parsedArgs{}, err
+		}
+		if fs.NArg() != 0 {
+			return parsedArgs{}, errors.New("zen session start does not accept extra arguments")
+		}
+		if *duration <= 0 {
+			return parsedArgs{}, errors.New("--duration must be positive")
+		}
+		return parsedArgs{
+			command:         commandSession,
+			action:          action,
+			profile:         strings.TrimSpace(*profile),
+			sessionDuration: *duration,
+			detach:          *detach,
+		}, nil
+	case sessionActionWait:
+		fs := flag.NewFlagSet("zen session wait", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		started := fs.String("started", "", "start time of the session to wait for")
+		if err := fs.Parse(args[1:]); err != nil {
+			return parsedArgs{}, err
+		}
+		startedAt, err := time.Parse(time.RFC3339Nano, *started)
+		if err != nil || fs.NArg() != 0 {
+			return parsedArgs{}, errors.New("zen session wait requires --started TIME")
+		}
+		return parsedArgs{command: commandSession, action: action, sessionStartedAt: startedAt}, nil
+	case sessionActionEnd, sessionActionStatus:
+		if len(args) != 1 {
+			return parsedArgs{}, fmt.Errorf("zen session %s does not accept extra arguments", action)
+		}
+		return parsedArgs{command: commandSession, action: action}, nil
+	}
+	return parsedArgs{}, fmt.Errorf("unknown session action %q", action)
+}
+
+func runSessionCommand(out io.Writer, executor zencli.Executor, configPath string, parsed parsedArgs) error {
+	statePath := sessionStatePath(configPath)
+	if parsed.action == sessionActionWait {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return waitForSession(ctx, out, executor, statePath, parsed.sessionStartedAt, sessionWaitPoll)
+	}
+
+	state, active, err := loadSessionState(statePath)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	switch parsed.action {
+	case sessionActionStatus:
+		printSessionStatus(out, state, active, now)
+		return nil
+	case sessionActionEnd:
+		if !active {
+			return errors.New("no focus session is running")
+		}
+		return endSession(out, executor, statePath, state.StartedAt)
+	}
+
+	if active && state.expired(now) {
+		fmt.Fprintln(out, "zen-cli: the previous focus session has expired.")
+		if err := endSession(out, executor, statePath, state.StartedAt); err != nil {
+			return err
+		}
+		active = false
+	}
+	if active {
+		return fmt.Errorf("a focus session is already running until %s", state.EndsAt.Format("15:04"))
+	}
+
+	config, err := newConfigWatcher(configPath, parsed.profile, os.Stderr)
+	if err != nil {
+		return err
+	}
+	opts := watchOptions(config.Options())
+	hooks, err := loadHooksFromConfig(configPath, false)
+	if err != nil {
+		return err
+	}
+	runner := hookRunner{executor: executor, hooks: hooks, profile: parsed.profile, log: os.Stderr}
+
+	results, quitErr := quitWithHooks(executor, runner, opts)
+	if results == nil && quitErr != nil {
+		return quitErr
+	}
+
+	state = sessionState{
+		StartedAt:  now,
+		EndsAt:     now.Add(parsed.sessionDuration),
+		Profile:    parsed.profile,
+		ClosedApps: snapshotAppsOf(zencli.ClosedAppInfos(results)),
+	}
+	if err := withConfigLock(statePath, func() error { return saveSessionState(statePath, state) }); err != nil {
+		return err
+	}
+
+	entry := newRunHistoryEntry(historySessionStart, parsed.profile, zencli.EffectiveAllowedApps(opts), results)
+	entry.Time = now
+	recordHistory(entry)
+	snap := newSnapshot(historySessionStart, parsed.profile, results)
+	snap.Time = now
+	recordSnapshot(snap)
+
+	fmt.Fprintf(out, "zen-cli session started for %s, ends at %s.\n", parsed.sessionDuration, state.EndsAt.Format("15:04"))
+	printQuitSummary(out, results)
+	if quitErr != nil {
+		fmt.Fprintln(out, "zen-cli: some apps could not be closed; the session continues without them.")
+	}
+	if parsed.detach {
+		if err := startSessionWaiter(state); err != nil {
+			fmt.Fprintf(os.Stderr, "zen-cli: %v; run zen session end to relaunch the apps.\n", err)
+		}
+		return nil
+	}
+
+	// The session outlives its terminal: closing it (SIGHUP) leaves this
+	// process running so it still ends the session on time. Ctrl-C ends it
+	// early.
+	signal.Ignore(syscall.SIGHUP)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	reloads := config.Subscribe()
+	go config.Run(ctx)
+
+	// Like the background waiter, recheck the wall clock and the state file
+	// regularly: timers stall while the machine sleeps, and the session may
+	// be ended from another terminal.
+	timer := time.NewTimer(min(state.remaining(time.Now()), sessionWaitPoll))
+	defer timer.Stop()
+	for waiting := true; waiting; {
+		select {
+		case <-timer.C:
+			current, active, err := loadSessionState(statePath)
+			if err != nil {
+				return err
+			}
+			if !active || !current.StartedAt.Equal(state.StartedAt) {
+				fmt.Fprintln(out, "zen-cli: the focus session already ended.")
+				return nil
+			}
+			state = current
+			if state.expired(time.Now()) {
+				waiting = false
+				continue
+			}
+			timer.Reset(min(state.remaining(time.Now()), sessionWaitPoll))
+		case <-ctx.Done():
+			fmt.Fprintln(out, "zen-cli: session interrupted.")
+			waiting = false
+		case reload, ok := <-reloads:
+			if !ok {
+				reloads = nil
+				continue
+			}
+			if reload.Err != nil {
+				continue
+			}
+			if state, err = applySessionReload(out, executor, statePath, state, reload.Options); err != nil {
+				fmt.Fprintf(os.Stderr, "zen-cli: failed to apply the reloaded config: %v\n", err)
+			}
+		}
+	}
+	return endSession(out, executor, statePath, state.StartedAt)
+}
+
+// applySessionReload closes the apps a reloaded config no longer allows.
+// They join the apps relaunched when the session ends.
+func applySessionReload(out io.Writer, executor zencli.Executor, statePath string, state sessionState, opts zencli.Options) (sessionState, error) {
+	results, err := zencli.ExecuteWithOptions(executor, watchOptions(opts))
+	closed := snapshotAppsOf(zencli.ClosedAppInfos(results))
+	if len(closed) > 0 {
+		state.ClosedApps = mergeSessionApps(state.ClosedApps, closed)
+		saveErr := withConfigLock(statePath, func() error {
+			current, active, err := loadSessionState(statePath)
+			if err != nil || !active || !current.StartedAt.Equal(state.StartedAt) {
+				return err
+			}
+			current.ClosedApps = mergeSessionApps(current.ClosedApps, closed)
+			state = current
+			return saveSessionState(statePath, state)
+		})
+		if saveErr != nil {
+			return state, saveErr
+		}
+	}
+	if len(results) > 0 {
+		fmt.Fprintln(out, "zen-cli: config reloaded; closing apps it no longer allows.")
+		printQuitSummary(out, results)
+	}
+	return state, err
+}
+
+// mergeSessionApps appends the apps of incoming not in base yet, comparing
+// names case-insensitively.
+func mergeSessionApps(base []snapshotApp, incoming []snapshotApp) []snapshotApp {
+	merged := append([]snapshotApp{}, base...)
+	for _, app := range incoming {
+		known := false
+		for _, existing := range merged {
+			if strings.EqualFold(existing.App, app.App) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			merged = append(merged, app)
+		}
+	}
+	return merged
+}
+
+// startSessionWaiter runs zen session wait in the background, detached from
+// the terminal, so a detached session still relaunches its apps on time.
+func startSessionWaiter(state sessionState) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to start the session timer: %w", err)
+	}
+	cmd := exec.Command(exe, string(commandSession), sessionActionWait, "--started", state.StartedAt.Format(time.RFC3339Nano))
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start the session timer: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// waitForSession ends the session that started at started once it expires.
+// It returns early when that session is ended or replaced by another one,
+// and when ctx is done; the session is then finished by the next zen
+// session start or end instead.
+func waitForSession(ctx context.Context, out io.Writer, executor zencli.Executor, statePath string, started time.Time, poll time.Duration) error {
+	for {
+		state, active, err := loadSessionState(statePath)
+		if err != nil {
+			return err
+		}
+		if !active || !state.StartedAt.Equal(started) {
+			return nil
+		}
+		now := time.Now()
+		if state.expired(now) {
+			return endSession(out, executor, statePath, started)
+		}
+
+		timer := time.NewTimer(min(state.remaining(now), poll))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// endSession relaunches the apps closed by the session that started at
+// started and removes the state file. It holds the session lock throughout,
+// so a session ended concurrently by the waiter and by zen session end is
+// only ended once; the later call finds it gone and does nothing. The state
+// is removed even when some apps fail to launch so a broken app does not pin
+// the session forever.
+func endSession(out io.Writer, executor zencli.Executor, statePath string, started time.Time) error {
+	return withConfigLock(statePath, func() error {
+		state, active, err := loadSessionState(statePath)
+		if err != nil {
+			return err
+		}
+		if !active || !state.StartedAt.Equal(started) {
+			fmt.Fprintln(out, "zen-cli: the focus session already ended.")
+			return nil
+		}
+		return finishSession(out, executor, statePath, state)
+	})
+}
+
+func finishSession(out io.Writer, executor zencli.Executor, statePath string, state sessionState) error {
+	launched, launchErr := zencli.RelaunchApps(executor, appInfosOf(state.ClosedApps))
+	if err := clearSessionState(statePath); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	recordHistory(historyEntry{
+		Time:            now,
+		Command:         historySessionEnd,
+		Profile:         reportProfile(state.Profile),
+		Targets:         snapshotAppNames(state.ClosedApps),
+		Relaunched:      launched,
+		DurationSeconds: int64(now.Sub(state.StartedAt).Seconds()),
+	})
+
+	fmt.Fprintln(out, "zen-cli session ended.")
+	if len(launched) > 0 {
+		fmt.Fprintln(out, "zen-cli relaunched apps:")
+		for _, app := range launched {
+			fmt.Fprintf(out, "- %s\n", app)
+		}
+	}
+	return launchErr
+}
+
+func printSessionStatus(out io.Writer, state sessionState, active bool, now time.Time) {
+	if !active {
+		fmt.Fprintln(out, "zen-cli: no focus session is running.")
+		return
+	}
+
+	if state.expired(now) {
+		fmt.Fprintf(out, "zen-cli: the focus session ended at %s; run zen session end to relaunch its apps.\n", state.EndsAt.Format("15:04"))
+		printClosedApps(out, snapshotAppNames(state.ClosedApps))
+		return
+	}
+
+	remaining := state.remaining(now).Round(time.Second)
+	fmt.Fprintf(out, "zen-cli session: %s remaining (ends at %s).\n", remaining, state.EndsAt.Format("15:04"))
+	if state.Profile != "" {
+		fmt.Fprintf(out, "Profile: %s\n", state.Profile)
+	}
+	printClosedApps(out, snapshotAppNames(state.ClosedApps))
+}
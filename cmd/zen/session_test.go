@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionStatePath(t *testing.T) {
+	got := sessionStatePath(filepath.Join("/tmp", "zen-cli", "config.json"))
+	want := filepath.Join("/tmp", "zen-cli", "session.json")
+	if got != want {
+		t.Fatalf("unexpected path: got %q want %q", got, want)
+	}
+}
+
+func TestSessionStateRoundtrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	state := sessionState{
+		StartedAt:  start,
+		EndsAt:     start.Add(50 * time.Minute),
+		Profile:    "coding",
+		ClosedApps: []snapshotApp{{App: "Safari", BundleID: "com.apple.Safari"}, {App: "Slack"}},
+	}
+
+	if err := saveSessionState(path, state); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	got, active, err := loadSessionState(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !active {
+		t.Fatal("expected session to be active")
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Fatalf("unexpected state: got %+v want %+v", got, state)
+	}
+
+	if err := clearSessionState(path); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, active, _ := loadSessionState(path); active {
+		t.Fatal("expected session to be cleared")
+	}
+	if err := clearSessionState(path); err != nil {
+		t.Fatalf("expected clearing a missing session to succeed, got %v", err)
+	}
+}
+
+func TestLoadSessionStateAcceptsAppNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	raw := `{"startedAt": "2026-03-02T09:00:00Z", "endsAt": "2026-03-02T09:50:00Z", "closedApps": ["Safari"]}`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	state, _, err := loadSessionState(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(state.ClosedApps, []snapshotApp{{App: "Safari"}}) {
+		t.Fatalf("unexpected closed apps: %+v", state.ClosedApps)
+	}
+}
+
+func TestMergeSessionApps(t *testing.T) {
+	base := []snapshotApp{{App: "Safari", BundleID: "com.apple.Safari"}}
+	got := mergeSessionApps(base, []snapshotApp{{App: "safari"}, {App: "Slack", BundleID: "com.tinyspeck.slackmacgap"}})
+	want := []snapshotApp{{App: "Safari", BundleID: "com.apple.Safari"}, {App: "Slack", BundleID: "com.tinyspeck.slackmacgap"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected apps: got %+v want %+v", got, want)
+	}
+}
+
+func TestWaitForSessionEndsExpiredSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	start := time.Now().Add(-time.Hour)
+	state := sessionState{StartedAt: start, EndsAt: start.Add(50 * time.Minute), ClosedApps: []snapshotApp{{App: "Slack"}}}
+	if err := saveSessionState(path, state); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	executor := &recordingExecutor{}
+	var out bytes.Buffer
+	if err := waitForSession(context.Background(), &out, executor, path, start, time.Millisecond); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, active, _ := loadSessionState(path); active {
+		t.Fatal("expected session to be ended")
+	}
+	if len(executor.commands) != 1 {
+		t.Fatalf("expected one relaunch, got %v", executor.commands)
+	}
+	if !strings.Contains(out.String(), "zen-cli session ended.") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestWaitForSessionIgnoresOtherSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	start := time.Now().Add(-time.Hour)
+	state := sessionState{StartedAt: start, EndsAt: start.Add(50 * time.Minute), ClosedApps: []snapshotApp{{App: "Slack"}}}
+	if err := saveSessionState(path, state); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	executor := &recordingExecutor{}
+	if err := waitForSession(context.Background(), &bytes.Buffer{}, executor, path, start.Add(-time.Minute), time.Millisecond); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, active, _ := loadSessionState(path); !active {
+		t.Fatal("expected the other session to keep running")
+	}
+	if len(executor.commands) != 0 {
+		t.Fatalf("expected no relaunch, got %v", executor.commands)
+	}
+}
+
+func TestWaitForSessionStopsWithContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	start := time.Now()
+	if err := saveSessionState(path, sessionState{StartedAt: start, EndsAt: start.Add(time.Hour)}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := waitForSession(ctx, &bytes.Buffer{}, &recordingExecutor{}, path, start, time.Hour); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, active, _ := loadSessionState(path); !active {
+		t.Fatal("expected the session to keep running")
+	}
+}
+
+func TestEndSessionEndsSessionOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	start := time.Now().Add(-time.Hour)
+	state := sessionState{StartedAt: start, EndsAt: start.Add(50 * time.Minute), ClosedApps: []snapshotApp{{App: "Slack"}}}
+	if err := saveSessionState(path, state); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	executor := &recordingExecutor{}
+	if err := endSession(&bytes.Buffer{}, executor, path, start); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	var out bytes.Buffer
+	if err := endSession(&out, executor, path, start); err != nil {
+		t.Fatalf("expected ending an ended session to succeed, got %v", err)
+	}
+	if len(executor.commands) != 1 {
+		t.Fatalf("expected one relaunch, got %v", executor.commands)
+	}
+	if out.String() != "zen-cli: the focus session already ended.\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestRunSessionStatusLeavesExpiredSession(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	statePath := sessionStatePath(configPath)
+	start := time.Now().Add(-time.Hour)
+	state := sessionState{StartedAt: start, EndsAt: start.Add(50 * time.Minute), ClosedApps: []snapshotApp{{App: "Slack"}}}
+	if err := saveSessionState(statePath, state); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	executor := &recordingExecutor{}
+	var out bytes.Buffer
+	if err := runSessionCommand(&out, executor, configPath, parsedArgs{command: commandSession, action: sessionActionStatus}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(executor.commands) != 0 {
+		t.Fatalf("expected no relaunch, got %v", executor.commands)
+	}
+	if _, active, _ := loadSessionState(statePath); !active {
+		t.Fatal("expected status to leave the session in place")
+	}
+	if !strings.Contains(out.String(), "run zen session end to relaunch its apps") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestSessionStateRemaining(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	state := sessionState{StartedAt: start, EndsAt: start.Add(50 * time.Minute)}
+
+	if got := state.remaining(start.Add(20 * time.Minute)); got != 30*time.Minute {
+		t.Fatalf("unexpected remaining: %s", got)
+	}
+	if state.expired(start.Add(49 * time.Minute)) {
+		t.Fatal("expected session to be running")
+	}
+	if got := state.remaining(start.Add(2 * time.Hour)); got != 0 {
+		t.Fatalf("unexpected remaining: %s", got)
+	}
+	if !state.expired(start.Add(50 * time.Minute)) {
+		t.Fatal("expected session to be expired")
+	}
+}
+
+func TestParseSessionArgsStart(t *testing.T) {
+	parsed, err := parseSessionArgs([]string{"start", "--duration", "25m", "--profile", "writing", "--detach"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.command != commandSession || parsed.action != sessionActionStart {
+		t.Fatalf("unexpected parse result: %+v", parsed)
+	}
+	if parsed.sessionDuration != 25*time.Minute {
+		t.Fatalf("unexpected duration: %s", parsed.sessionDuration)
+	}
+	if parsed.profile != "writing" || !parsed.detach {
+		t.Fatalf("unexpected parse result: %+v", parsed)
+	}
+}
+
+func TestParseSessionArgsStartDefaultDuration(t *testing.T) {
+	parsed, err := parseSessionArgs([]string{"start"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if parsed.sessionDuration != defaultSessionDuration {
+		t.Fatalf("unexpected duration: %s", parsed.sessionDuration)
+	}
+}
+
+func TestParseSessionArgsErrors(t *testing.T) {
+	cases := [][]string{
+		nil,
+		{"pause"},
+		{"start", "--duration", "-5m"},
+		{"start", "extra"},
+		{"status", "extra"},
+		{"wait"},
+		{"wait", "--started", "soon"},
+	}
+	for _, args := range cases {
+		if _, err := parseSessionArgs(args); err == nil {
+			t.Fatalf("expected error for %v, got nil", args)
+		}
+	}
+}
+
+func TestParseSessionArgsWait(t *testing.T) {
+	parsed, err := parseSessionArgs([]string{"wait", "--started", "2026-03-02T09:00:00.5+01:00"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := time.Date(2026, 3, 2, 8, 0, 0, 500000000, time.UTC)
+	if parsed.action != sessionActionWait || !parsed.sessionStartedAt.Equal(want) {
+		t.Fatalf("unexpected parse result: %+v", parsed)
+	}
+}
+
+func TestPrintSessionStatus(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	state := sessionState{
+		StartedAt:  start,
+		EndsAt:     start.Add(50 * time.Minute),
+		ClosedApps: []snapshotApp{{App: "Slack"}},
+	}
+
+	var out bytes.Buffer
+	printSessionStatus(&out, state, true, start.Add(10*time.Minute))
+
+	want := "zen-cli session: 40m0s remaining (ends at 09:50).\nzen-cli closed apps:\n- Slack\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintSessionStatusInactive(t *testing.T) {
+	var out bytes.Buffer
+	printSessionStatus(&out, sessionState{}, false, time.Now())
+
+	want := "zen-cli: no focus session is running.\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const outputCSV outputFormat = "csv"
+
+const defaultStatsTop = 10
+
+// statsReport aggregates the history log. Focus time counts focus sessions,
+// watches and schedule windows; sessions per day counts focus sessions only.
+type statsReport struct {
+	Command           string     `json:"command"`
+	Sessions          int        `json:"sessions"`
+	TotalFocusSeconds int64      `json:"totalFocusSeconds"`
+	Days              []dayStats `json:"days"`
+	MostClosed        []appCount `json:"mostClosed"`
+	MostRelaunched    []appCount `json:"mostRelaunched"`
+}
+
+type dayStats struct {
+	Date         string `json:"date"`
+	Sessions     int    `json:"sessions"`
+	FocusSeconds int64  `json:"focusSeconds"`
+}
+
+type appCount struct {
+	App   string `json:"app"`
+	Count int    `json:"count"`
+}
+
+// computeStats aggregates entries by local day in loc and keeps the top apps
+// of each ranking. An app closed n times during one watch or schedule window
+// was relaunched n-1 times in between.
+func computeStats(entries []historyEntry, loc *time.Location, top int) statsReport {
+	report := statsReport{Command: string(commandStats)}
+	days := make(map[string]*dayStats)
+	day := func(t time.Time) *dayStats {
+		date := t.In(loc).Format("2006-01-02")
+		if days[date] == nil {
+			days[date] = &dayStats{Date: date}
+		}
+		return days[date]
+	}
+
+	closed := make(map[string]int)
+	relaunched := make(map[string]int)
+	names := make(map[string]string)
+	count := func(counts map[string]int, app string, n int) {
+		key := strings.ToLower(app)
+		if _, ok := names[key]; !ok {
+			names[key] = app
+		}
+		counts[key] += n
+	}
+
+	for _, entry := range entries {
+		switch entry.Command {
+		case historySessionStart:
+			report.Sessions++
+			day(entry.Time).Sessions++
+		case historySessionEnd, historyWatch, historySchedule:
+			report.TotalFocusSeconds += entry.DurationSeconds
+			// Attribute focus time to the day the focus period started.
+			started := entry.Time
+			if entry.Command == historySessionEnd {
+				started = entry.Time.Add(-time.Duration(entry.DurationSeconds) * time.Second)
+			}
+			day(started).FocusSeconds += entry.DurationSeconds
+		}
+
+		if entry.Command != historyRun && entry.Command != historySessionStart && entry.Command != historyWatch && entry.Command != historySchedule {
+			continue
+		}
+		perEntry := make(map[string]int)
+		for _, result := range entry.Results {
+			if result.Status != statusClosed {
+				continue
+			}
+			count(closed, result.App, 1)
+			perEntry[result.App]++
+		}
+		if entry.Command == historyWatch || entry.Command == historySchedule {
+			for app, n := range perEntry {
+				if n > 1 {
+					count(relaunched, app, n-1)
+				}
+			}
+		}
+	}
+
+	report.Days = make([]dayStats, 0, len(days))
+	for _, stats := range days {
+		report.Days = append(report.Days, *stats)
+	}
+	sort.Slice(report.Days, func(i, j int) bool {
+		return report.Days[i].Date < report.Days[j].Date
+	})
+	report.MostClosed = rankApps(closed, names, top)
+	report.MostRelaunched = rankApps(relaunched, names, top)
+	return report
+}
+
+func rankApps(counts map[string]int, names map[string]string, top int) []appCount {
+	ranked := make([]appCount, 0, len(counts))
+	for key, n := range counts {
+		ranked = append(ranked, appCount{App: names[key], Count: n})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].App < ranked[j].App
+	})
+	if top > 0 && len(ranked) > top {
+		ranked = ranked[:top]
+	}
+	return ranked
+}
+
+func parseStatsArgs(args []string) (parsedArgs, error) {
+	fs := flag.NewFlagSet("zen stats", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	since := fs.String("since", "", "only count entries newer than this, e.g. 30d")
+	output := fs.String("output", string(outputText), "output format: text, csv or json")
+	top := fs.Int("top", defaultStatsTop, "number of apps in each ranking")
+	if err := fs.Parse(args); err != nil {
+		return parsedArgs{}, err
+	}
+	if fs.NArg() != 0 {
+		return parsedArgs{}, errors.New("zen stats does not accept extra arguments")
+	}
+	if *top < 1 {
+		return parsedArgs{}, errors.New("--top must be at least 1")
+	}
+
+	var format outputFormat
+	switch raw := outputFormat(strings.ToLower(strings.TrimSpace(*output))); raw {
+	case "", outputText:
+		format = outputText
+	case outputCSV, outputJSON:
+		format = raw
+	default:
+		return parsedArgs{}, fmt.Errorf("unknown output format %q: expected text, csv or json", *output)
+	}
+
+	var window time.Duration
+	if strings.TrimSpace(*since) != "" {
+		var err error
+		if window, err = parseSince(*since); err != nil {
+			return parsedArgs{}, err
+		}
+	}
+
+	return parsedArgs{command: commandStats, output: format, historySince: window, statsTop: *top}, nil
+}
+
+func runStatsCommand(out io.Writer, parsed parsedArgs) error {
+	path, err := defaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	entries, err := readHistory(path)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if parsed.historySince > 0 {
+		since = time.Now().Add(-parsed.historySince)
+	}
+	report := computeStats(filterHistory(entries, since, ""), time.Local, parsed.statsTop)
+	return printStats(out, parsed.output, report)
+}
+
+func printStats(out io.Writer, format outputFormat, report statsReport) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(out, report)
+	case outputCSV:
+		return writeStatsCSV(out, report)
+	}
+
+	fmt.Fprintf(out, "Focus sessions: %d\n", report.Sessions)
+	fmt.Fprintf(out, "Total focus time: %s\n", formatSeconds(report.TotalFocusSeconds))
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "DATE\tSESSIONS\tFOCUS")
+	for _, day := range report.Days {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", day.Date, day.Sessions, formatSeconds(day.FocusSeconds))
+	}
+	printRanking(tw, "MOST CLOSED", report.MostClosed)
+	printRanking(tw, "MOST RELAUNCHED", report.MostRelaunched)
+	return tw.Flush()
+}
+
+func printRanking(tw io.Writer, title string, ranked []appCount) {
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "%s\tCOUNT\n", title)
+	if len(ranked) == 0 {
+		fmt.Fprintln(tw, "(none)\t")
+	}
+	for _, app := range ranked {
+		fmt.Fprintf(tw, "%s\t%d\n", app.App, app.Count)
+	}
+}
+
+// writeStatsCSV writes the report in long format, one metric per row, so it
+// loads into a spreadsheet without reshaping.
+func writeStatsCSV(out io.Writer, report statsReport) error {
+	w := csv.NewWriter(out)
+	rows := [][]string{
+		{"metric", "key", "value"},
+		{"sessions", "", strconv.Itoa(report.Sessions)},
+		{"total_focus_seconds", "", strconv.FormatInt(report.TotalFocusSeconds, 10)},
+	}
+	for _, day := range report.Days {
+		rows = append(rows,
+			[]string{"day_sessions", day.Date, strconv.Itoa(day.Sessions)},
+			[]string{"day_focus_seconds", day.Date, strconv.FormatInt(day.FocusSeconds, 10)},
+		)
+	}
+	for _, app := range report.MostClosed {
+		rows = append(rows, []string{"closed", app.App, strconv.Itoa(app.Count)})
+	}
+	for _, app := range report.MostRelaunched {
+		rows = append(rows, []string{"relaunched", app.App, strconv.Itoa(app.Count)})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+func formatSeconds(seconds int64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
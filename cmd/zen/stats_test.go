@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func statsFixture() []historyEntry {
+	day := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	closed := func(apps ...string) []appResult {
+		results := make([]appResult, 0, len(apps))
+		for _, app := range apps {
+			results = append(results, appResult{App: app, Status: statusClosed})
+		}
+		return results
+	}
+
+	return []historyEntry{
+		{Time: day, Command: historySessionStart, Results: closed("Slack", "Music")},
+		{Time: day.Add(50 * time.Minute), Command: historySessionEnd, DurationSeconds: 3000},
+		{Time: day.Add(2 * time.Hour), Command: historyRun, Results: append(closed("slack"), appResult{App: "Mail", Status: statusFailed})},
+		{Time: day.Add(3 * time.Hour), Command: historyDryRun, Targets: []string{"Slack"}},
+		{Time: day.Add(24 * time.Hour), Command: historySessionStart, Results: closed("Slack")},
+		{Time: day.Add(24*time.Hour + 25*time.Minute), Command: historySessionEnd, DurationSeconds: 1500},
+		{Time: day.Add(26 * time.Hour), Command: historyWatch, DurationSeconds: 3600, Results: closed("Slack", "Music", "Slack", "Slack", "Music")},
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	got := computeStats(statsFixture(), time.UTC, 10)
+
+	want := statsReport{
+		Command:           "stats",
+		Sessions:          2,
+		TotalFocusSeconds: 3000 + 1500 + 3600,
+		Days: []dayStats{
+			{Date: "2026-03-02", Sessions: 1, FocusSeconds: 3000},
+			{Date: "2026-03-03", Sessions: 1, FocusSeconds: 1500 + 3600},
+		},
+		MostClosed:     []appCount{{App: "Slack", Count: 6}, {App: "Music", Count: 3}},
+		MostRelaunched: []appCount{{App: "Slack", Count: 2}, {App: "Music", Count: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected stats:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestComputeStatsUsesLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	entries := []historyEntry{{Time: time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC), Command: historySessionStart}}
+
+	got := computeStats(entries, tokyo, 10)
+	if len(got.Days) != 1 || got.Days[0].Date != "2026-03-03" {
+		t.Fatalf("unexpected days: %+v", got.Days)
+	}
+}
+
+func TestComputeStatsTop(t *testing.T) {
+	got := computeStats(statsFixture(), time.UTC, 1)
+	if !reflect.DeepEqual(got.MostClosed, []appCount{{App: "Slack", Count: 6}}) {
+		t.Fatalf("unexpected ranking: %+v", got.MostClosed)
+	}
+}
+
+func TestComputeStatsCountsScheduleWindows(t *testing.T) {
+	day := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	entries := []historyEntry{{
+		Time:            day,
+		Command:         historySchedule,
+		DurationSeconds: 1800,
+		Results:         []appResult{{App: "Slack", Status: statusClosed}, {App: "Slack", Status: statusClosed}},
+	}}
+
+	got := computeStats(entries, time.UTC, 10)
+	if got.TotalFocusSeconds != 1800 {
+		t.Fatalf("unexpected focus time: got %d want %d", got.TotalFocusSeconds, 1800)
+	}
+	if !reflect.DeepEqual(got.MostRelaunched, []appCount{{App: "Slack", Count: 1}}) {
+		t.Fatalf("unexpected relaunches: %+v", got.MostRelaunched)
+	}
+}
+
+func TestComputeStatsEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := printStats(&out, outputJSON, computeStats(nil, time.UTC, 10)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "{\n  \"command\": \"stats\",\n  \"sessions\": 0,\n  \"totalFocusSeconds\": 0,\n" +
+		"  \"days\": [],\n  \"mostClosed\": [],\n  \"mostRelaunched\": []\n}\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintStatsText(t *testing.T) {
+	var out bytes.Buffer
+	if err := printStats(&out, outputText, computeStats(statsFixture(), time.UTC, 10)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "Focus sessions: 2\n" +
+		"Total focus time: 2h15m0s\n" +
+		"\n" +
+		"DATE        SESSIONS  FOCUS\n" +
+		"2026-03-02  1         50m0s\n" +
+		"2026-03-03  1         1h25m0s\n" +
+		"\n" +
+		"MOST CLOSED  COUNT\n" +
+		"Slack        6\n" +
+		"Music        3\n" +
+		"\n" +
+		"MOST RELAUNCHED  COUNT\n" +
+		"Slack            2\n" +
+		"Music            1\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestPrintStatsCSV(t *testing.T) {
+	var out bytes.Buffer
+	if err := printStats(&out, outputCSV, computeStats(statsFixture(), time.UTC, 10)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := "metric,key,value\n" +
+		"sessions,,2\n" +
+		"total_focus_seconds,,8100\n" +
+		"day_sessions,2026-03-02,1\n" +
+		"day_focus_seconds,2026-03-02,3000\n" +
+		"day_sessions,2026-03-03,1\n" +
+		"day_focus_seconds,2026-03-03,5100\n" +
+		"closed,Slack,6\n" +
+		"closed,Music,3\n" +
+		"relaunched,Slack,2\n" +
+		"relaunched,Music,1\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
+
+func TestParseStatsArgs(t *testing.T) {
+	parsed, err := optionsFromArgs([]string{"stats", "--since", "30d", "--output", "csv", "--top", "3"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if parsed.command != commandStats || parsed.output != outputCSV || parsed.historySince != 30*24*time.Hour || parsed.statsTop != 3 {
+		t.Fatalf("unexpected parsed args: %+v", parsed)
+	}
+
+	for _, args := range [][]string{{"stats", "--output", "ndjson"}, {"stats", "--top", "0"}, {"stats", "extra"}} {
+		if _, err := optionsFromArgs(args); err == nil {
+			t.Fatalf("expected error for %v, got nil", args)
+		}
+	}
+}
@@ -0,0 +1,625 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tomlCodec handles TOML configs: tables, arrays of tables, dotted and
+// quoted keys, basic and literal strings, numbers, booleans, arrays and
+// inline tables. Multi-line strings and dates are rejected since no config
+// field uses them.
+type tomlCodec struct{}
+
+func (tomlCodec) name() string { return "toml" }
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+	root *configObject
+	// defined holds the tables created by a header, which
+	// may not be opened again by another header.
+	defined map[*configObject]bool
+	// inline holds the values that may not be extended, such as inline
+	// tables and plain arrays.
+	inline map[any]bool
+}
+
+func (tomlCodec) decode(raw []byte) (any, error) {
+	p := &tomlParser{
+		src:     strings.ReplaceAll(string(raw), "\r\n", "\n"),
+		line:    1,
+		root:    newConfigObject(),
+		defined: make(map[*configObject]bool),
+		inline:  make(map[any]bool),
+	}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line, err)
+	}
+	return p.root, nil
+}
+
+func (p *tomlParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments, as allowed between the
+// elements of an array.
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if p.peek() != '\n' {
+			return
+		}
+		p.pos++
+		p.line++
+	}
+}
+
+// endLine expects the rest of the line to be blank or a comment.
+func (p *tomlParser) endLine() error {
+	p.skipSpaces()
+	p.skipComment()
+	switch p.peek() {
+	case 0:
+		return nil
+	case '\n':
+		p.pos++
+		p.line++
+		return nil
+	}
+	return fmt.Errorf("unexpected %q at end of line", p.rest())
+}
+
+func (p *tomlParser) rest() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		return p.src[p.pos:]
+	}
+	return p.src[p.pos : p.pos+end]
+}
+
+func (p *tomlParser) parse() error {
+	current := p.root
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.src) {
+			return nil
+		}
+
+		var err error
+		if p.peek() == '[' {
+			current, err = p.parseHeader()
+		} else {
+			err = p.parseKeyValue(current)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.endLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseHeader() (*configObject, error) {
+	p.pos++
+	array := p.peek() == '['
+	if array {
+		p.pos++
+	}
+	keys, err := p.parseKey()
+	if err != nil {
+		return nil, err
+	}
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.src[p.pos:], closing) {
+		return nil, fmt.Errorf("expected %s after table name", closing)
+	}
+	p.pos += len(closing)
+
+	parent, err := p.descend(p.root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	existing, exists := parent.get(last)
+
+	if array {
+		table := newConfigObject()
+		if !exists {
+			parent.set(last, []any{table})
+			return table, nil
+		}
+		items, ok := existing.([]any)
+		if !ok || !isTOMLTableArray(items) || p.inline[sliceKey(items)] {
+			return nil, fmt.Errorf("cannot redefine %q as an array of tables", strings.Join(keys, "."))
+		}
+		parent.set(last, append(items, table))
+		return table, nil
+	}
+
+	if !exists {
+		table := newConfigObject()
+		parent.set(last, table)
+		p.defined[table] = true
+		return table, nil
+	}
+	table, ok := existing.(*configObject)
+	if !ok || p.defined[table] || p.inline[table] {
+		return nil, fmt.Errorf("table %q is defined more than once", strings.Join(keys, "."))
+	}
+	p.defined[table] = true
+	return table, nil
+}
+
+// sliceKey identifies an array by its first element so inline arrays can be
+// told apart from arrays of tables.
+func sliceKey(items []any) any {
+	if len(items) == 0 {
+		return nil
+	}
+	if table, ok := items[0].(*configObject); ok {
+		return table
+	}
+	return nil
+}
+
+// descend walks to the table at keys below table, creating implicit tables
+// on the way. For arrays of tables it continues in the last element.
+func (p *tomlParser) descend(table *configObject, keys []string) (*configObject, error) {
+	for _, key := range keys {
+		value, exists := table.get(key)
+		if !exists {
+			child := newConfigObject()
+			table.set(key, child)
+			table = child
+			continue
+		}
+		switch v := value.(type) {
+		case *configObject:
+			if p.inline[v] {
+				return nil, fmt.Errorf("cannot extend inline table %q", key)
+			}
+			table = v
+		case []any:
+			last, ok := sliceKey(v).(*configObject)
+			if !ok || p.inline[last] {
+				return nil, fmt.Errorf("key %q is not a table", key)
+			}
+			table = v[len(v)-1].(*configObject)
+		default:
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+func (p *tomlParser) parseKeyValue(table *configObject) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if p.peek() != '=' {
+		return fmt.Errorf("expected = after key %q", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpaces()
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	return p.assign(table, keys, value)
+}
+
+func (p *tomlParser) assign(table *configObject, keys []string, value any) error {
+	parent, err := p.descend(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := parent.get(last); exists {
+		return fmt.Errorf("duplicate key %q", strings.Join(keys, "."))
+	}
+	parent.set(last, value)
+	return nil
+}
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// parseKey parses a possibly dotted key and the spaces after it.
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaces()
+		var key string
+		switch p.peek() {
+		case '"', '\'':
+			quoted, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = quoted
+		default:
+			start := p.pos
+			for p.pos < len(p.src) && isTOMLBareKeyByte(p.src[p.pos]) {
+				p.pos++
+			}
+			key = p.src[start:p.pos]
+			if key == "" {
+				return nil, fmt.Errorf("expected a key, found %q", p.rest())
+			}
+		}
+		keys = append(keys, key)
+		p.skipSpaces()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isTOMLBareKeyByte(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.parseString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case c == 0 || c == '\n':
+		return nil, fmt.Errorf("missing value")
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\n,]}#", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	return resolveTOMLScalar(p.src[start:p.pos])
+}
+
+var (
+	tomlInteger = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)$`)
+	tomlFloat   = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][-+]?[0-9](_?[0-9])*)?$`)
+)
+
+func resolveTOMLScalar(text string) (any, error) {
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if tomlInteger.MatchString(text) || tomlFloat.MatchString(text) {
+		number := strings.TrimPrefix(strings.ReplaceAll(text, "_", ""), "+")
+		return json.Number(number), nil
+	}
+	return nil, fmt.Errorf("unsupported value %q", text)
+}
+
+func (p *tomlParser) parseString() (string, error) {
+	quote := p.peek()
+	if strings.HasPrefix(p.src[p.pos:], strings.Repeat(string(quote), 3)) {
+		return "", fmt.Errorf("multi-line strings are not supported")
+	}
+	p.pos++
+
+	var buf strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == '\n':
+			return "", fmt.Errorf("unterminated string")
+		case c == quote:
+			return buf.String(), nil
+		case c == '\\' && quote == '"':
+			if err := p.parseEscape(&buf); err != nil {
+				return "", err
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *tomlParser) parseEscape(buf *strings.Builder) error {
+	if p.pos >= len(p.src) {
+		return fmt.Errorf("unterminated escape")
+	}
+	e := p.src[p.pos]
+	p.pos++
+	switch e {
+	case 'b':
+		buf.WriteByte('\b')
+	case 't':
+		buf.WriteByte('\t')
+	case 'n':
+		buf.WriteByte('\n')
+	case 'f':
+		buf.WriteByte('\f')
+	case 'r':
+		buf.WriteByte('\r')
+	case '"', '\\':
+		buf.WriteByte(e)
+	case 'u', 'U':
+		size := 4
+		if e == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return fmt.Errorf("invalid escape \\%c", e)
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid escape \\%c%s", e, p.src[p.pos:p.pos+size])
+		}
+		buf.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return fmt.Errorf("unsupported escape \\%c", e)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseArray() (any, error) {
+	p.pos++
+	items := []any{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			break
+		}
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, fmt.Errorf("expected , or ] in array")
+		}
+	}
+	if table, ok := sliceKey(items).(*configObject); ok {
+		p.inline[table] = true
+	}
+	return items, nil
+}
+
+func (p *tomlParser) parseInlineTable() (any, error) {
+	p.pos++
+	table := newConfigObject()
+	p.skipSpaces()
+	if p.peek() == '}' {
+		p.pos++
+		p.inline[table] = true
+		return table, nil
+	}
+	for {
+		keys, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != '=' {
+			return nil, fmt.Errorf("expected = after key %q", strings.Join(keys, "."))
+		}
+		p.pos++
+		p.skipSpaces()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.assign(table, keys, value); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			p.inline[table] = true
+			return table, nil
+		default:
+			return nil, fmt.Errorf("expected , or } in inline table")
+		}
+	}
+}
+
+func (tomlCodec) encode(tree any) ([]byte, error) {
+	root, ok := tree.(*configObject)
+	if !ok {
+		return nil, fmt.Errorf("a TOML document must be a table")
+	}
+	var buf bytes.Buffer
+	if err := writeTOMLTable(&buf, root, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeTOMLTable writes the plain fields of table, then its subtables and
+// arrays of tables under their own headers. Null values have no TOML form
+// and are left out.
+func writeTOMLTable(buf *bytes.Buffer, table *configObject, path []string) error {
+	var tables, arrays []string
+	for _, key := range table.keys {
+		value := table.values[key]
+		switch v := value.(type) {
+		case nil:
+			continue
+		case *configObject:
+			tables = append(tables, key)
+			continue
+		case []any:
+			if isTOMLTableArray(v) {
+				arrays = append(arrays, key)
+				continue
+			}
+		}
+		inline, err := tomlInline(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(append(path, key), "."), err)
+		}
+		buf.WriteString(tomlKey(key) + " = " + inline + "\n")
+	}
+
+	for _, key := range tables {
+		child := table.values[key].(*configObject)
+		childPath := append(append([]string{}, path...), key)
+		if hasTOMLFields(child) || len(child.keys) == 0 {
+			writeTOMLHeader(buf, "["+tomlPath(childPath)+"]")
+		}
+		if err := writeTOMLTable(buf, child, childPath); err != nil {
+			return err
+		}
+	}
+	for _, key := range arrays {
+		childPath := append(append([]string{}, path...), key)
+		for _, item := range table.values[key].([]any) {
+			writeTOMLHeader(buf, "[["+tomlPath(childPath)+"]]")
+			if err := writeTOMLTable(buf, item.(*configObject), childPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeTOMLHeader(buf *bytes.Buffer, header string) {
+	if buf.Len() > 0 {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(header + "\n")
+}
+
+// hasTOMLFields reports whether table has values that are written under its
+// own header rather than under a subtable's.
+func hasTOMLFields(table *configObject) bool {
+	for _, key := range table.keys {
+		switch v := table.values[key].(type) {
+		case nil, *configObject:
+		case []any:
+			if !isTOMLTableArray(v) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func isTOMLTableArray(items []any) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := item.(*configObject); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlInline(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", fmt.Errorf("null values cannot be written as TOML")
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return tomlString(v), nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			part, err := tomlInline(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case *configObject:
+		parts := make([]string, 0, len(v.keys))
+		for _, key := range v.keys {
+			if v.values[key] == nil {
+				continue
+			}
+			part, err := tomlInline(v.values[key])
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlKey(key)+" = "+part)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+func tomlPath(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = tomlKey(key)
+	}
+	return strings.Join(quoted, ".")
+}
+
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString writes a basic string. JSON escapes are valid in TOML except
+// for the control characters JSON writes as \u00XX, which TOML also
+// accepts.
+func tomlString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func tomlToJSON(t *testing.T, raw string) string {
+	t.Helper()
+	tree, err := tomlCodec{}.decode([]byte(raw))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	body, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return string(body)
+}
+
+func TestTOMLDecode(t *testing.T) {
+	raw := `# zen-cli config
+version = 1
+allowedApps = [
+  "Slack", # chat
+  'C:\Apps\Code',
+]
+replaceDefaultAllowed = false
+
+[profiles.writing]
+allowedApps = ["Notes"]
+
+[hooks]
+timeout = "30s"
+preRun = ["echo start", { command = "say focus", timeout = "5s" }]
+
+[[hooks.perApp]]
+command = "echo $ZEN_APP"
+`
+	want := `{"version":1,"allowedApps":["Slack","C:\\Apps\\Code"],"replaceDefaultAllowed":false,` +
+		`"profiles":{"writing":{"allowedApps":["Notes"]}},` +
+		`"hooks":{"timeout":"30s","preRun":["echo start",{"command":"say focus","timeout":"5s"}],"perApp":[{"command":"echo $ZEN_APP"}]}}`
+	if got := tomlToJSON(t, raw); got != want {
+		t.Fatalf("unexpected tree: got %s want %s", got, want)
+	}
+}
+
+func TestTOMLDecodeScalars(t *testing.T) {
+	raw := "a = +1_000\nb = -2.5e3\nc = \"\\u00e9\\t\"\n\"quoted key\".x = true\n"
+	want := `{"a":1000,"b":-2.5e3,"c":"é\t","quoted key":{"x":true}}`
+	if got := tomlToJSON(t, raw); got != want {
+		t.Fatalf("unexpected tree: got %s want %s", got, want)
+	}
+}
+
+func TestTOMLDecodeErrors(t *testing.T) {
+	cases := map[string]string{
+		"a = 1\na = 2\n":       "duplicate key",
+		"[t]\n[t]\n":           "more than once",
+		"a = [1]\n[[a]]\n":     "array of tables",
+		"a = {x = 1}\n[a.y]\n": "inline table",
+		"a = \"\"\"x\"\"\"\n":  "multi-line",
+		"a = 1979-05-27\n":     "unsupported value",
+		"a = 1 b = 2\n":        "end of line",
+		"a = \"open\n":         "unterminated",
+		"a\n":                  "expected =",
+	}
+	for raw, part := range cases {
+		_, err := tomlCodec{}.decode([]byte(raw))
+		if err == nil || !strings.Contains(err.Error(), part) {
+			t.Fatalf("expected %q error for %q, got %v", part, raw, err)
+		}
+	}
+}
+
+func TestTOMLRoundTrip(t *testing.T) {
+	raw := `{"version":1,"allowedApps":["Slack","re:^Code$"],"disallowedApps":[],` +
+		`"profiles":{"writing":{"allowedApps":["Notes"]},"empty":{}},` +
+		`"hooks":{"preRun":["echo",{"command":"say \"hi\"","timeout":"2s"}],"perApp":[{"command":"a"},{"command":"b"}]},` +
+		`"odd key":{"x.y":true}}`
+	tree, err := jsonCodec{}.decode([]byte(raw))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	body, err := tomlCodec{}.encode(tree)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := tomlToJSON(t, string(body)); got != raw {
+		t.Fatalf("unexpected round trip: got %s want %s\n%s", got, raw, body)
+	}
+}
+
+func TestTOMLEncodeSkipsNull(t *testing.T) {
+	tree, err := jsonCodec{}.decode([]byte(`{"version":1,"hooks":null,"allowedApps":["Slack"]}`))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	body, err := tomlCodec{}.encode(tree)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := "version = 1\nallowedApps = [\"Slack\"]\n"; string(body) != want {
+		t.Fatalf("unexpected toml: got %q want %q", body, want)
+	}
+}
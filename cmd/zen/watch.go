@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+const defaultWatchInterval = 10 * time.Second
+
+func parseWatchArgs(args []string) (parsedArgs, error) {
+	fs := flag.NewFlagSet("zen watch", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	interval := fs.Duration("interval", defaultWatchInterval, "time between two scans")
+	duration := fs.Duration("for", 0, "stop watching after this long")
+	until := fs.String("until", "", "stop watching at this local time (HH:MM)")
+	profile := fs.String("profile", "", "named profile from the config file")
+	if err := fs.Parse(args); err != nil {
+		return parsedArgs{}, err
+	}
+	if fs.NArg() != 0 {
+		return parsedArgs{}, errors.New("zen watch does not accept extra arguments")
+	}
+	if *interval < zencli.MinWatchInterval {
+		return parsedArgs{}, fmt.Errorf("--interval must be at least %s", zencli.MinWatchInterval)
+	}
+	if *duration < 0 {
+		return parsedArgs{}, errors.New("--for must be positive")
+	}
+	if *duration > 0 && strings.TrimSpace(*until) != "" {
+		return parsedArgs{}, errors.New("--for and --until cannot be used together")
+	}
+
+	return parsedArgs{
+		command:       commandWatch,
+		profile:       strings.TrimSpace(*profile),
+		watchInterval: *interval,
+		watchFor:      *duration,
+		watchUntil:    strings.TrimSpace(*until),
+	}, nil
+}
+
+// watchDeadline resolves --for or --until against now. --until refers to the
+// next occurrence of the given wall-clock time.
+func watchDeadline(duration time.Duration, until string, now time.Time) (time.Time, error) {
+	if duration > 0 {
+		return now.Add(duration), nil
+	}
+	if until == "" {
+		return time.Time{}, nil
+	}
+
+	clock, err := time.ParseInLocation("15:04", until, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --until %q: expected HH:MM", until)
+	}
+	deadline := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !deadline.After(now) {
+		deadline = deadline.AddDate(0, 0, 1)
+	}
+	return deadline, nil
+}
+
+// watchOptions returns the options of a watch scan.
+func watchOptions(opts zencli.Options) zencli.Options {
+	opts.Escalation.GracePeriod = zencli.DefaultGracePeriod
+	return opts
+}
+
+func runWatchCommand(out io.Writer, executor zencli.Executor, configPath string, parsed parsedArgs) error {
+	config, err := newConfigWatcher(configPath, parsed.profile, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	deadline, err := watchDeadline(parsed.watchFor, parsed.watchUntil, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// The watcher stops with the loop, including when the deadline passes.
+	configCtx, stopConfig := context.WithCancel(ctx)
+	defer stopConfig()
+	go config.Run(configCtx)
+
+	if deadline.IsZero() {
+		fmt.Fprintf(out, "zen-cli watching every %s (Ctrl-C to stop).\n", parsed.watchInterval)
+	} else {
+		fmt.Fprintf(out, "zen-cli watching every %s until %s (Ctrl-C to stop).\n", parsed.watchInterval, deadline.Format("15:04"))
+	}
+
+	started := time.Now()
+	var attempts []zencli.QuitResult
+	err = zencli.Watch(ctx, executor, watchOptions(config.Options()), zencli.WatchOptions{
+		Interval: parsed.watchInterval,
+		Deadline: deadline,
+		OnQuit: func(event zencli.WatchEvent) {
+			attempts = append(attempts, event.Result)
+			printWatchEvent(out, event)
+		},
+		OnScanError: func(event zencli.WatchEvent) {
+			printWatchEvent(out, event)
+		},
+		Options: func(time.Time) (zencli.Options, bool) {
+			return watchOptions(config.Options()), true
+		},
+	})
+
+	// Every attempt is kept, so an app closed three times appears three
+	// times in the entry. The allow-list is the one in effect at the end.
+	entry := newRunHistoryEntry(historyWatch, parsed.profile, zencli.EffectiveAllowedApps(config.Options()), attempts)
+	entry.Time = started
+	entry.DurationSeconds = int64(time.Since(started).Seconds())
+	recordHistory(entry)
+	snap := newSnapshot(historyWatch, parsed.profile, attempts)
+	snap.Time = started
+	recordSnapshot(snap)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "zen-cli watch stopped.")
+	return nil
+}
+
+func printWatchEvent(out io.Writer, event zencli.WatchEvent) {
+	stamp := event.Time.Format("15:04:05")
+	if event.App == "" {
+		fmt.Fprintf(out, "[%s] failed to list running apps, retrying: %v\n", stamp, event.Err)
+		return
+	}
+	if event.Err != nil {
+		fmt.Fprintf(out, "[%s] failed to close %s: %v\n", stamp, event.App, event.Err)
+		return
+	}
+	fmt.Fprintf(out, "[%s] closed %s\n", stamp, event.App)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"zen-cli/internal/zencli"
+)
+
+func TestParseWatchArgs(t *testing.T) {
+	parsed, err := parseWatchArgs([]string{"--interval", "30s", "--for", "2h", "--profile", "coding"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if parsed.command != commandWatch {
+		t.Fatalf("unexpected command: %s", parsed.command)
+	}
+	if parsed.watchInterval != 30*time.Second || parsed.watchFor != 2*time.Hour {
+		t.Fatalf("unexpected parse result: %+v", parsed)
+	}
+	if parsed.profile != "coding" {
+		t.Fatalf("unexpected profile: %q", parsed.profile)
+	}
+}
+
+func TestParseWatchArgsDefaults(t *testing.T) {
+	parsed, err := parseWatchArgs(nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if parsed.watchInterval != defaultWatchInterval {
+		t.Fatalf("unexpected interval: %s", parsed.watchInterval)
+	}
+}
+
+func TestParseWatchArgsErrors(t *testing.T) {
+	cases := [][]string{
+		{"--interval", "100ms"},
+		{"--for", "1h", "--until", "17:00"},
+		{"extra"},
+	}
+	for _, args := range cases {
+		if _, err := parseWatchArgs(args); err == nil {
+			t.Fatalf("expected error for %v, got nil", args)
+		}
+	}
+}
+
+func TestWatchDeadline(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+
+	got, err := watchDeadline(0, "17:00", now)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := time.Date(2026, 3, 2, 17, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("unexpected deadline: got %s want %s", got, want)
+	}
+
+	got, err = watchDeadline(0, "08:00", now)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("unexpected deadline: got %s want %s", got, want)
+	}
+
+	got, err = watchDeadline(45*time.Minute, "", now)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := now.Add(45 * time.Minute); !got.Equal(want) {
+		t.Fatalf("unexpected deadline: got %s want %s", got, want)
+	}
+
+	got, err = watchDeadline(0, "", now)
+	if err != nil || !got.IsZero() {
+		t.Fatalf("expected no deadline, got %s, %v", got, err)
+	}
+
+	if _, err := watchDeadline(0, "5pm", now); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestPrintWatchEvent(t *testing.T) {
+	at := time.Date(2026, 3, 2, 9, 30, 5, 0, time.UTC)
+
+	var out bytes.Buffer
+	printWatchEvent(&out, zencli.WatchEvent{Time: at, App: "Slack"})
+	printWatchEvent(&out, zencli.WatchEvent{Time: at, App: "Music", Err: errors.New("quit refused")})
+	printWatchEvent(&out, zencli.WatchEvent{Time: at, Err: errors.New("wmctrl failed")})
+
+	want := "[09:30:05] closed Slack\n[09:30:05] failed to close Music: quit refused\n[09:30:05] failed to list running apps, retrying: wmctrl failed\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output: got %q want %q", out.String(), want)
+	}
+}
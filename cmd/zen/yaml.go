@@ -0,0 +1,604 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// yamlCodec handles the subset of YAML configs need: block mappings and
+// sequences, flow sequences and mappings on one line, quoted and plain
+// scalars, and comments. Anchors, tags, multi-document files and block
+// scalars (| and >) are rejected rather than misread.
+type yamlCodec struct{}
+
+func (yamlCodec) name() string { return "yaml" }
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (yamlCodec) decode(raw []byte) (any, error) {
+	lines, err := splitYAMLLines(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	p := &yamlParser{lines: lines}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	value, err := p.parseNode(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, p.errorf(p.lines[p.pos], "unexpected indentation")
+	}
+	return value, nil
+}
+
+func splitYAMLLines(raw string) ([]yamlLine, error) {
+	var lines []yamlLine
+	for i, text := range strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n") {
+		number := i + 1
+		content := strings.TrimRight(stripYAMLComment(text), " \t")
+		trimmed := strings.TrimLeft(content, " ")
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", number)
+		}
+		indent := len(content) - len(trimmed)
+		if indent == 0 && (trimmed == "---" || trimmed == "...") {
+			if len(lines) > 0 && trimmed == "---" {
+				return nil, fmt.Errorf("line %d: multiple documents are not supported", number)
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "%") && indent == 0 {
+			return nil, fmt.Errorf("line %d: directives are not supported", number)
+		}
+		lines = append(lines, yamlLine{number: number, indent: indent, text: trimmed})
+	}
+	return lines, nil
+}
+
+// stripYAMLComment removes a # comment, which starts at the beginning of a
+// line or after whitespace, outside of quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"':
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quote = 0
+			}
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[{,:-", rune(line[i-1])) {
+				quote = c
+			}
+		case c == '#':
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func (p *yamlParser) errorf(line yamlLine, format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", line.number, fmt.Sprintf(format, args...))
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseNode parses the block starting at the current line, which must be
+// indented by at least minIndent.
+func (p *yamlParser) parseNode(minIndent int) (any, error) {
+	if p.pos >= len(p.lines) || p.lines[p.pos].indent < minIndent {
+		return nil, nil
+	}
+	line := p.lines[p.pos]
+	if isYAMLSequenceItem(line.text) {
+		return p.parseSequence(line.indent)
+	}
+	if _, _, ok, err := splitYAMLMapping(line.text); err != nil {
+		return nil, p.errorf(line, "%v", err)
+	} else if ok {
+		return p.parseMapping(line.indent)
+	}
+
+	p.pos++
+	value, err := parseYAMLFlow(line.text)
+	if err != nil {
+		return nil, p.errorf(line, "%v", err)
+	}
+	return value, nil
+}
+
+func (p *yamlParser) parseSequence(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isYAMLSequenceItem(line.text) {
+			break
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			item, err := p.parseNode(indent + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// "- key: value" starts a mapping whose keys line up with key, and
+		// "- - x" a nested sequence: reparse the rest as its own line.
+		offset := len(line.text) - len(rest)
+		_, _, isMapping, err := splitYAMLMapping(rest)
+		if err != nil {
+			return nil, p.errorf(line, "%v", err)
+		}
+		if isMapping || isYAMLSequenceItem(rest) {
+			p.lines[p.pos] = yamlLine{number: line.number, indent: indent + offset, text: rest}
+			item, err := p.parseNode(indent + offset)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		p.pos++
+		item, err := parseYAMLFlow(rest)
+		if err != nil {
+			return nil, p.errorf(line, "%v", err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (any, error) {
+	obj := newConfigObject()
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf(line, "unexpected indentation")
+		}
+		if isYAMLSequenceItem(line.text) {
+			break
+		}
+
+		key, rest, ok, err := splitYAMLMapping(line.text)
+		if err != nil {
+			return nil, p.errorf(line, "%v", err)
+		}
+		if !ok {
+			return nil, p.errorf(line, "expected \"key: value\"")
+		}
+		if _, exists := obj.get(key); exists {
+			return nil, p.errorf(line, "duplicate key %q", key)
+		}
+		p.pos++
+
+		var value any
+		switch {
+		case rest != "":
+			value, err = parseYAMLFlow(rest)
+			if err != nil {
+				return nil, p.errorf(line, "%v", err)
+			}
+		case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+			value, err = p.parseNode(indent + 1)
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text):
+			// A sequence may sit at the indentation of its key.
+			value, err = p.parseSequence(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		obj.set(key, value)
+	}
+	return obj, nil
+}
+
+// splitYAMLMapping splits "key: value" at the first colon outside quotes
+// that is followed by a space or ends the line.
+func splitYAMLMapping(text string) (string, string, bool, error) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false, nil
+	}
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") {
+		key, n, err := parseYAMLQuoted(text)
+		if err != nil {
+			return "", "", false, err
+		}
+		rest := strings.TrimLeft(text[n:], " ")
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false, nil
+		}
+		after := rest[1:]
+		if after != "" && after[0] != ' ' {
+			return "", "", false, nil
+		}
+		return key, strings.TrimSpace(after), true, nil
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			key := strings.TrimSpace(text[:i])
+			if key == "" {
+				return "", "", false, nil
+			}
+			return key, strings.TrimSpace(text[i+1:]), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// parseYAMLFlow parses a scalar or a one-line flow collection.
+func parseYAMLFlow(text string) (any, error) {
+	switch text[0] {
+	case '|', '>':
+		return nil, fmt.Errorf("block scalars are not supported")
+	case '&', '*':
+		return nil, fmt.Errorf("anchors and aliases are not supported")
+	case '!':
+		return nil, fmt.Errorf("tags are not supported")
+	}
+
+	if text[0] != '[' && text[0] != '{' && text[0] != '"' && text[0] != '\'' {
+		return resolveYAMLPlain(text), nil
+	}
+	value, n, err := parseYAMLFlowValue(text, 0)
+	if err != nil {
+		return nil, err
+	}
+	if rest := strings.TrimSpace(text[n:]); rest != "" {
+		return nil, fmt.Errorf("unexpected %q after value", rest)
+	}
+	return value, nil
+}
+
+func skipYAMLSpaces(text string, i int) int {
+	for i < len(text) && (text[i] == ' ' || text[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// parseYAMLFlowValue parses one value inside a flow collection starting at
+// i and returns it with the index after it.
+func parseYAMLFlowValue(text string, i int) (any, int, error) {
+	i = skipYAMLSpaces(text, i)
+	if i >= len(text) {
+		return nil, i, fmt.Errorf("unexpected end of line; flow collections must fit on one line")
+	}
+
+	switch text[i] {
+	case '"', '\'':
+		value, n, err := parseYAMLQuoted(text[i:])
+		return value, i + n, err
+	case '[':
+		items := []any{}
+		i = skipYAMLSpaces(text, i+1)
+		if i < len(text) && text[i] == ']' {
+			return items, i + 1, nil
+		}
+		for {
+			item, next, err := parseYAMLFlowValue(text, i)
+			if err != nil {
+				return nil, next, err
+			}
+			items = append(items, item)
+			i = skipYAMLSpaces(text, next)
+			if i >= len(text) {
+				return nil, i, fmt.Errorf("unterminated flow sequence; flow collections must fit on one line")
+			}
+			if text[i] == ']' {
+				return items, i + 1, nil
+			}
+			if text[i] != ',' {
+				return nil, i, fmt.Errorf("expected , or ] in flow sequence")
+			}
+			i = skipYAMLSpaces(text, i+1)
+			if i < len(text) && text[i] == ']' {
+				return items, i + 1, nil
+			}
+		}
+	case '{':
+		obj := newConfigObject()
+		i = skipYAMLSpaces(text, i+1)
+		if i < len(text) && text[i] == '}' {
+			return obj, i + 1, nil
+		}
+		for {
+			key, next, err := parseYAMLFlowKey(text, i)
+			if err != nil {
+				return nil, next, err
+			}
+			value, next, err := parseYAMLFlowValue(text, next)
+			if err != nil {
+				return nil, next, err
+			}
+			if _, exists := obj.get(key); exists {
+				return nil, next, fmt.Errorf("duplicate key %q", key)
+			}
+			obj.set(key, value)
+			i = skipYAMLSpaces(text, next)
+			if i >= len(text) {
+				return nil, i, fmt.Errorf("unterminated flow mapping; flow collections must fit on one line")
+			}
+			if text[i] == '}' {
+				return obj, i + 1, nil
+			}
+			if text[i] != ',' {
+				return nil, i, fmt.Errorf("expected , or } in flow mapping")
+			}
+			i = skipYAMLSpaces(text, i+1)
+		}
+	}
+
+	start := i
+	for i < len(text) && !strings.ContainsRune(",]}", rune(text[i])) {
+		i++
+	}
+	return resolveYAMLPlain(strings.TrimSpace(text[start:i])), i, nil
+}
+
+func parseYAMLFlowKey(text string, i int) (string, int, error) {
+	i = skipYAMLSpaces(text, i)
+	var key string
+	if i < len(text) && (text[i] == '"' || text[i] == '\'') {
+		quoted, n, err := parseYAMLQuoted(text[i:])
+		if err != nil {
+			return "", i, err
+		}
+		key, i = quoted, i+n
+	} else {
+		start := i
+		for i < len(text) && text[i] != ':' && !strings.ContainsRune(",]}", rune(text[i])) {
+			i++
+		}
+		key = strings.TrimSpace(text[start:i])
+	}
+	i = skipYAMLSpaces(text, i)
+	if i >= len(text) || text[i] != ':' {
+		return "", i, fmt.Errorf("expected \"key: value\" in flow mapping")
+	}
+	return key, i + 1, nil
+}
+
+// yamlEscapes maps the single-character escapes of double-quoted scalars to
+// the characters they stand for. JSON escapes such as \b and \f, which
+// yamlString emits, are a subset.
+var yamlEscapes = map[byte]rune{
+	'0': 0, 'a': '\a', 'b': '\b', 't': '\t', '\t': '\t', 'n': '\n', 'v': '\v', 'f': '\f',
+	'r': '\r', 'e': 0x1b, ' ': ' ', '"': '"', '/': '/', '\\': '\\',
+	'N': 0x85, '_': 0xa0, 'L': 0x2028, 'P': 0x2029,
+}
+
+// parseYAMLQuoted parses the quoted scalar text starts with and returns it
+// with the number of bytes it used.
+func parseYAMLQuoted(text string) (string, int, error) {
+	quote := text[0]
+	var buf strings.Builder
+	for i := 1; i < len(text); i++ {
+		c := text[i]
+		if quote == '\'' {
+			if c == '\'' {
+				if i+1 < len(text) && text[i+1] == '\'' {
+					buf.WriteByte('\'')
+					i++
+					continue
+				}
+				return buf.String(), i + 1, nil
+			}
+			buf.WriteByte(c)
+			continue
+		}
+
+		switch c {
+		case '"':
+			return buf.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(text) {
+				return "", i, fmt.Errorf("unterminated escape")
+			}
+			i++
+			switch e := text[i]; e {
+			case 'u', 'U', 'x':
+				size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+				if i+size >= len(text) {
+					return "", i, fmt.Errorf("invalid escape \\%c", e)
+				}
+				code, err := strconv.ParseUint(text[i+1:i+1+size], 16, 32)
+				if err != nil {
+					return "", i, fmt.Errorf("invalid escape \\%c%s", e, text[i+1:i+1+size])
+				}
+				buf.WriteRune(rune(code))
+				i += size
+			default:
+				r, ok := yamlEscapes[e]
+				if !ok {
+					return "", i, fmt.Errorf("unsupported escape \\%c", e)
+				}
+				buf.WriteRune(r)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", len(text), fmt.Errorf("unterminated quoted string")
+}
+
+var yamlNumber = regexp.MustCompile(`^[-+]?(\d+|\d+\.\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// resolveYAMLPlain turns a plain scalar into null, a bool, a number or a
+// string, following the YAML 1.2 core schema.
+func resolveYAMLPlain(text string) any {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlNumber.MatchString(text) {
+		return json.Number(strings.TrimPrefix(text, "+"))
+	}
+	return text
+}
+
+func (yamlCodec) encode(tree any) ([]byte, error) {
+	var buf bytes.Buffer
+	switch tree.(type) {
+	case *configObject, []any:
+		if isEmptyYAMLCollection(tree) {
+			buf.WriteString(yamlFlowScalar(tree))
+			buf.WriteByte('\n')
+		} else {
+			writeYAMLBlock(&buf, tree, 0)
+		}
+	default:
+		buf.WriteString(yamlFlowScalar(tree))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func isEmptyYAMLCollection(value any) bool {
+	switch v := value.(type) {
+	case *configObject:
+		return len(v.keys) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// writeYAMLBlock writes a non-empty mapping or sequence in block style.
+func writeYAMLBlock(buf *bytes.Buffer, value any, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch v := value.(type) {
+	case *configObject:
+		for _, key := range v.keys {
+			buf.WriteString(pad + yamlKey(key) + ":")
+			writeYAMLChild(buf, v.values[key], indent)
+		}
+	case []any:
+		for _, item := range v {
+			buf.WriteString(pad + "-")
+			if obj, ok := item.(*configObject); ok && len(obj.keys) > 0 {
+				// The first key shares the line with the dash.
+				var nested bytes.Buffer
+				writeYAMLBlock(&nested, obj, indent+2)
+				buf.WriteString(" " + strings.TrimLeft(nested.String(), " "))
+				continue
+			}
+			writeYAMLChild(buf, item, indent)
+		}
+	}
+}
+
+func writeYAMLChild(buf *bytes.Buffer, value any, indent int) {
+	switch value.(type) {
+	case *configObject, []any:
+		if !isEmptyYAMLCollection(value) {
+			buf.WriteByte('\n')
+			writeYAMLBlock(buf, value, indent+2)
+			return
+		}
+	}
+	buf.WriteString(" " + yamlFlowScalar(value) + "\n")
+}
+
+// yamlFlowScalar formats scalars and empty collections.
+func yamlFlowScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	case *configObject:
+		return "{}"
+	case []any:
+		return "[]"
+	}
+	return fmt.Sprint(value)
+}
+
+func yamlKey(key string) string {
+	return yamlString(key)
+}
+
+// yamlString writes s plain when that reads back as the same string, and
+// double-quoted otherwise. JSON strings are valid YAML double-quoted
+// scalars.
+func yamlString(s string) string {
+	if isPlainYAMLString(s) {
+		return s
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func isPlainYAMLString(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) || !utf8.ValidString(s) {
+		return false
+	}
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(s[0])) {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return false
+		}
+	}
+	// YAML 1.1 tools read these as booleans.
+	switch strings.ToLower(s) {
+	case "y", "n", "yes", "no", "on", "off":
+		return false
+	}
+	_, isString := resolveYAMLPlain(s).(string)
+	return isString
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func yamlToJSON(t *testing.T, raw string) string {
+	t.Helper()
+	tree, err := yamlCodec{}.decode([]byte(raw))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	body, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return string(body)
+}
+
+func TestYAMLDecode(t *testing.T) {
+	raw := `# zen-cli config
+version: 1
+allowedApps:
+- Slack   # chat
+- "Visual Studio Code"
+- 'it''s'
+disallowedApps: []
+replaceDefaultAllowed: false
+profiles:
+  writing:
+    allowedApps: [Notes, "re:^Obsidian$"]
+hooks:
+  preRun:
+    - echo start
+    - command: say "focus"
+      timeout: 5s
+  timeout: 30
+confirmNonInteractive: ~
+`
+	want := `{"version":1,"allowedApps":["Slack","Visual Studio Code","it's"],"disallowedApps":[],` +
+		`"replaceDefaultAllowed":false,"profiles":{"writing":{"allowedApps":["Notes","re:^Obsidian$"]}},` +
+		`"hooks":{"preRun":["echo start",{"command":"say \"focus\"","timeout":"5s"}],"timeout":30},` +
+		`"confirmNonInteractive":null}`
+	if got := yamlToJSON(t, raw); got != want {
+		t.Fatalf("unexpected tree: got %s want %s", got, want)
+	}
+}
+
+func TestYAMLDecodeScalars(t *testing.T) {
+	raw := "a: 1.5\nb: TRUE\nc: yes\nd: \"tab\\there\"\ne: {x: 1, 'y': [a, b]}\nf: http://example.com\n"
+	want := `{"a":1.5,"b":true,"c":"yes","d":"tab\there","e":{"x":1,"y":["a","b"]},"f":"http://example.com"}`
+	if got := yamlToJSON(t, raw); got != want {
+		t.Fatalf("unexpected tree: got %s want %s", got, want)
+	}
+}
+
+func TestYAMLDecodeErrors(t *testing.T) {
+	cases := map[string]string{
+		"a: 1\na: 2\n":           "duplicate key",
+		"a: |\n  text\n":         "block scalars",
+		"a: &x 1\n":              "anchors",
+		"a: [1, 2\n":             "one line",
+		"a: 1\n---\nb: 2\n":      "multiple documents",
+		"a:\n  b: 1\n    c: 2\n": "indentation",
+		"a: \"open\n":            "unterminated",
+	}
+	for raw, part := range cases {
+		_, err := yamlCodec{}.decode([]byte(raw))
+		if err == nil || !strings.Contains(err.Error(), part) {
+			t.Fatalf("expected %q error for %q, got %v", part, raw, err)
+		}
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	raw := `{"version":1,"allowedApps":["Slack","re:^Code$","- dash","true","",": x","on"],"disallowedApps":[],` +
+		`"profiles":{"writing":{"allowedApps":["Notes"]},"empty":{}},` +
+		`"hooks":{"perApp":[{"command":"echo \"$ZEN_APP\"","timeout":"2s"}],"timeout":null},"nested":[[1,2],[]]}`
+	tree, err := jsonCodec{}.decode([]byte(raw))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	body, err := yamlCodec{}.encode(tree)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := yamlToJSON(t, string(body)); got != raw {
+		t.Fatalf("unexpected round trip: got %s want %s\n%s", got, raw, body)
+	}
+}
+
+func TestYAMLRoundTripControlCharacters(t *testing.T) {
+	var all strings.Builder
+	for c := rune(0); c < ' '; c++ {
+		all.WriteRune(c)
+	}
+	all.WriteString("\x7f\u0085\u2028")
+	for _, value := range []string{"form\ffeed", "back\bspace", "bell\a", "vertical\vtab", "esc\x1b[0m", all.String()} {
+		raw, err := json.Marshal(map[string]string{"command": value})
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		tree, err := jsonCodec{}.decode(raw)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		body, err := yamlCodec{}.encode(tree)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if got := yamlToJSON(t, string(body)); got != string(raw) {
+			t.Fatalf("unexpected round trip: got %s want %s\n%s", got, raw, body)
+		}
+	}
+}
+
+func TestYAMLDecodeEscapes(t *testing.T) {
+	raw := `a: "\a\b\e\f\v\0\N\_\L\P\x41\u00e9\/\ "` + "\n"
+	want, err := json.Marshal(map[string]string{"a": "\a\b\x1b\f\v\x00\u0085\u00a0\u2028\u2029Aé/ "})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := yamlToJSON(t, raw); got != string(want) {
+		t.Fatalf("unexpected tree: got %s want %s", got, want)
+	}
+}
+
+func TestYAMLEncodeStyle(t *testing.T) {
+	tree, err := jsonCodec{}.decode([]byte(`{"version":1,"allowedApps":["Slack","Visual Studio Code"],"profiles":{"writing":{"disallowedApps":[]}}}`))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	body, err := yamlCodec{}.encode(tree)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := "version: 1\nallowedApps:\n  - Slack\n  - Visual Studio Code\nprofiles:\n  writing:\n    disallowedApps: []\n"
+	if string(body) != want {
+		t.Fatalf("unexpected yaml: got %q want %q", body, want)
+	}
+}
@@ -0,0 +1,127 @@
+package zencli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultGracePeriod is how long an app gets to exit after a graceful
+	// quit before it is escalated.
+	DefaultGracePeriod = 5 * time.Second
+	// exitPollInterval is how often a quitting app is checked for exit.
+	exitPollInterval = 250 * time.Millisecond
+)
+
+// ErrStillRunning is reported for apps that outlived the grace period when
+// force killing is disabled.
+var ErrStillRunning = errors.New("did not exit within the grace period")
+
+// EscalationPolicy controls what happens to an app that is still running
+// after a graceful quit. The zero value escalates to SIGKILL immediately.
+type EscalationPolicy struct {
+	// GracePeriod is how long to wait for the app to exit after each step.
+	GracePeriod time.Duration
+	// Terminate sends SIGTERM, and waits another grace period, before
+	// resorting to SIGKILL.
+	Terminate bool
+	// NoForce never sends SIGKILL; apps that do not exit are reported with
+	// ErrStillRunning.
+	NoForce bool
+	// Clock defaults to SystemClock.
+	Clock Clock
+}
+
+func (p EscalationPolicy) clock() Clock {
+	if p.Clock == nil {
+		return SystemClock
+	}
+	return p.Clock
+}
+
+// quitApp asks the app to quit and escalates according to policy. When the
+// graceful quit fails the app is left alone rather than killed, since it may
+// be showing a dialog with unsaved work.
+func quitApp(executor Executor, platform Platform, app AppInfo, policy EscalationPolicy) QuitResult {
+	result := QuitResult{App: app.Name, BundleID: app.BundleID, Graceful: StepOK, Terminate: StepSkipped, ForceKill: StepSkipped}
+	if err := platform.Quit(executor, app); err != nil {
+		result.Graceful = StepFailed
+		result.Err = err
+		return result
+	}
+
+	exited, err := waitForExit(executor, platform, app, policy)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if exited {
+		return result
+	}
+
+	if policy.Terminate {
+		switch err := platform.Terminate(executor, app); {
+		case err == nil:
+			result.Terminate = StepOK
+		case errors.Is(err, ErrNotRunning):
+			result.Terminate = StepNotRunning
+			return result
+		default:
+			result.Terminate = StepFailed
+			result.Err = err
+			return result
+		}
+
+		exited, err := waitForExit(executor, platform, app, policy)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if exited {
+			return result
+		}
+	}
+
+	if policy.NoForce {
+		result.Err = fmt.Errorf("%s %w", app.Name, ErrStillRunning)
+		return result
+	}
+
+	switch err := platform.ForceQuit(executor, app); {
+	case err == nil:
+		result.ForceKill = StepOK
+	case errors.Is(err, ErrNotRunning):
+		result.ForceKill = StepNotRunning
+	default:
+		result.ForceKill = StepFailed
+		result.Err = err
+	}
+	return result
+}
+
+// waitForExit polls the app until it exits or the grace period runs out and
+// reports whether it exited. Without a grace period it checks once without
+// waiting, so an app that quit right away is not reported or killed.
+func waitForExit(executor Executor, platform Platform, app AppInfo, policy EscalationPolicy) (bool, error) {
+	clock := policy.clock()
+	deadline := clock.Now().Add(policy.GracePeriod)
+	for {
+		running, err := platform.IsRunning(executor, app)
+		if err != nil {
+			return false, err
+		}
+		if !running {
+			return true, nil
+		}
+
+		left := deadline.Sub(clock.Now())
+		if left <= 0 {
+			return false, nil
+		}
+		if left > exitPollInterval {
+			left = exitPollInterval
+		}
+		<-clock.After(left)
+	}
+}
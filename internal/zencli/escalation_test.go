@@ -0,0 +1,123 @@
+package zencli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuitAppExitsWithinGracePeriod(t *testing.T) {
+	fake := &fakePlatform{lingering: map[string]int{"Slack": 2}}
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	result := quitApp(&mockExecutor{}, fake, AppInfo{Name: "Slack"}, EscalationPolicy{GracePeriod: time.Second, Clock: clock})
+
+	want := QuitResult{App: "Slack", Graceful: StepOK, Terminate: StepSkipped, ForceKill: StepSkipped}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("unexpected result: got %+v want %+v", result, want)
+	}
+	if len(fake.forceQuit) != 0 {
+		t.Fatalf("expected no force quit, got %v", fake.forceQuit)
+	}
+	wantWaits := []time.Duration{exitPollInterval, exitPollInterval}
+	if !reflect.DeepEqual(clock.waits, wantWaits) {
+		t.Fatalf("unexpected waits: got %v want %v", clock.waits, wantWaits)
+	}
+}
+
+func TestQuitAppKillsAfterGracePeriod(t *testing.T) {
+	fake := &fakePlatform{lingering: map[string]int{"Slack": 100}}
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	result := quitApp(&mockExecutor{}, fake, AppInfo{Name: "Slack"}, EscalationPolicy{GracePeriod: 600 * time.Millisecond, Clock: clock})
+
+	want := QuitResult{App: "Slack", Graceful: StepOK, Terminate: StepSkipped, ForceKill: StepOK}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("unexpected result: got %+v want %+v", result, want)
+	}
+	wantWaits := []time.Duration{exitPollInterval, exitPollInterval, 100 * time.Millisecond}
+	if !reflect.DeepEqual(clock.waits, wantWaits) {
+		t.Fatalf("unexpected waits: got %v want %v", clock.waits, wantWaits)
+	}
+}
+
+func TestQuitAppTerminatesBeforeKilling(t *testing.T) {
+	fake := &fakePlatform{lingering: map[string]int{"Slack": 100}}
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	result := quitApp(&mockExecutor{}, fake, AppInfo{Name: "Slack"}, EscalationPolicy{GracePeriod: time.Second, Terminate: true, Clock: clock})
+
+	want := QuitResult{App: "Slack", Graceful: StepOK, Terminate: StepOK, ForceKill: StepOK}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("unexpected result: got %+v want %+v", result, want)
+	}
+	if !reflect.DeepEqual(fake.terminated, []string{"Slack"}) {
+		t.Fatalf("unexpected terminate calls: %v", fake.terminated)
+	}
+	if waited := clock.now.Sub(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)); waited != 2*time.Second {
+		t.Fatalf("expected two grace periods, waited %s", waited)
+	}
+}
+
+func TestQuitAppTerminateEnoughToExit(t *testing.T) {
+	fake := &fakePlatform{lingering: map[string]int{"Slack": 5}}
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	result := quitApp(&mockExecutor{}, fake, AppInfo{Name: "Slack"}, EscalationPolicy{GracePeriod: time.Second, Terminate: true, Clock: clock})
+
+	want := QuitResult{App: "Slack", Graceful: StepOK, Terminate: StepOK, ForceKill: StepSkipped}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("unexpected result: got %+v want %+v", result, want)
+	}
+	if len(fake.forceQuit) != 0 {
+		t.Fatalf("expected no force quit, got %v", fake.forceQuit)
+	}
+}
+
+func TestQuitAppNoForceReportsStillRunning(t *testing.T) {
+	fake := &fakePlatform{lingering: map[string]int{"Slack": 100}}
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	result := quitApp(&mockExecutor{}, fake, AppInfo{Name: "Slack"}, EscalationPolicy{GracePeriod: time.Second, NoForce: true, Clock: clock})
+
+	if !errors.Is(result.Err, ErrStillRunning) {
+		t.Fatalf("expected ErrStillRunning, got %v", result.Err)
+	}
+	if result.ForceKill != StepSkipped {
+		t.Fatalf("unexpected force-kill outcome: %s", result.ForceKill)
+	}
+	if len(fake.forceQuit) != 0 {
+		t.Fatalf("expected no force quit, got %v", fake.forceQuit)
+	}
+}
+
+func TestQuitAppWithoutGracePeriodChecksOnce(t *testing.T) {
+	fake := &fakePlatform{lingering: map[string]int{"Slack": 100}}
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	result := quitApp(&mockExecutor{}, fake, AppInfo{Name: "Slack"}, EscalationPolicy{Clock: clock})
+
+	if result.ForceKill != StepOK {
+		t.Fatalf("unexpected force-kill outcome: %s", result.ForceKill)
+	}
+	if len(clock.waits) != 0 || fake.lingering["Slack"] != 99 {
+		t.Fatalf("expected a single check without waiting, got waits %v and %d checks left", clock.waits, fake.lingering["Slack"])
+	}
+}
+
+func TestQuitAppWithoutGracePeriodSparesExitedApp(t *testing.T) {
+	for _, noForce := range []bool{false, true} {
+		fake := &fakePlatform{}
+		clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+		result := quitApp(&mockExecutor{}, fake, AppInfo{Name: "Slack"}, EscalationPolicy{NoForce: noForce, Clock: clock})
+
+		if result.Err != nil {
+			t.Fatalf("expected nil error with noForce %v, got %v", noForce, result.Err)
+		}
+		if result.ForceKill != StepSkipped || len(fake.forceQuit) != 0 {
+			t.Fatalf("expected no force quit with noForce %v, got %s %v", noForce, result.ForceKill, fake.forceQuit)
+		}
+	}
+}
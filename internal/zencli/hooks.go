@@ -0,0 +1,83 @@
+package zencli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultHookTimeout bounds a hook that does not set its own timeout.
+const DefaultHookTimeout = 30 * time.Second
+
+// ErrHookTimeout is returned for hooks that run longer than their timeout.
+var ErrHookTimeout = errors.New("hook timed out")
+
+// ContextExecutor is an Executor that can stop a command when ctx is done.
+// Hooks use it to enforce their timeout; other executors are abandoned in
+// the background instead.
+type ContextExecutor interface {
+	Executor
+	RunContext(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// HookEnv is the context passed to hooks as ZEN_* environment variables.
+type HookEnv struct {
+	Targets []string
+	App     string
+	Profile string
+	DryRun  bool
+}
+
+func (e HookEnv) environ() []string {
+	dryRun := "0"
+	if e.DryRun {
+		dryRun = "1"
+	}
+	return []string{
+		// Newlines, unlike commas, never appear in app names.
+		"ZEN_TARGETS=" + strings.Join(e.Targets, "\n"),
+		"ZEN_APP=" + e.App,
+		"ZEN_PROFILE=" + e.Profile,
+		"ZEN_DRY_RUN=" + dryRun,
+	}
+}
+
+// RunHook runs command with sh -c and the ZEN_* variables of env added to
+// the environment. It returns the combined output of the hook.
+func RunHook(executor Executor, command string, env HookEnv, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	args := append(env.environ(), "sh", "-c", command)
+
+	if ctxExecutor, ok := executor.(ContextExecutor); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		out, err := ctxExecutor.RunContext(ctx, "env", args...)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return out, fmt.Errorf("%w after %s", ErrHookTimeout, timeout)
+		}
+		return out, err
+	}
+
+	type hookResult struct {
+		out []byte
+		err error
+	}
+	done := make(chan hookResult, 1)
+	go func() {
+		out, err := executor.Run("env", args...)
+		done <- hookResult{out: out, err: err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-done:
+		return result.out, result.err
+	case <-timer.C:
+		return nil, fmt.Errorf("%w after %s", ErrHookTimeout, timeout)
+	}
+}
@@ -0,0 +1,83 @@
+package zencli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type blockingExecutor struct {
+	release chan struct{}
+}
+
+func (b blockingExecutor) Run(name string, args ...string) ([]byte, error) {
+	<-b.release
+	return nil, nil
+}
+
+type contextExecutor struct {
+	args []string
+}
+
+func (c *contextExecutor) Run(name string, args ...string) ([]byte, error) {
+	return nil, errors.New("Run should not be used")
+}
+
+func (c *contextExecutor) RunContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	c.args = append([]string{name}, args...)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRunHookPassesEnvironment(t *testing.T) {
+	executor := &mockExecutor{}
+	env := HookEnv{Targets: []string{"Safari", "Slack"}, App: "Slack", Profile: "coding", DryRun: true}
+
+	if _, err := RunHook(executor, "echo $ZEN_APP", env, time.Second); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := []string{"env|ZEN_TARGETS=Safari\nSlack|ZEN_APP=Slack|ZEN_PROFILE=coding|ZEN_DRY_RUN=1|sh|-c|echo $ZEN_APP"}
+	if !reflect.DeepEqual(executor.calls, want) {
+		t.Fatalf("unexpected calls: got %q want %q", executor.calls, want)
+	}
+}
+
+func TestRunHookReturnsFailure(t *testing.T) {
+	executor := &mockExecutor{results: map[string]callResult{
+		"env|ZEN_TARGETS=|ZEN_APP=|ZEN_PROFILE=|ZEN_DRY_RUN=0|sh|-c|false": {output: []byte("boom\n"), err: errors.New("exit status 1")},
+	}}
+
+	out, err := RunHook(executor, "false", HookEnv{}, time.Second)
+	if err == nil {
+		t.Fatal("expected hook error")
+	}
+	if string(out) != "boom\n" {
+		t.Fatalf("unexpected output: got %q want %q", out, "boom\n")
+	}
+}
+
+func TestRunHookTimesOut(t *testing.T) {
+	executor := blockingExecutor{release: make(chan struct{})}
+	defer close(executor.release)
+
+	_, err := RunHook(executor, "sleep 60", HookEnv{}, 10*time.Millisecond)
+	if !errors.Is(err, ErrHookTimeout) {
+		t.Fatalf("unexpected error: got %v want %v", err, ErrHookTimeout)
+	}
+}
+
+func TestRunHookCancelsContextExecutor(t *testing.T) {
+	executor := &contextExecutor{}
+
+	_, err := RunHook(executor, "sleep 60", HookEnv{Profile: "default"}, 10*time.Millisecond)
+	if !errors.Is(err, ErrHookTimeout) {
+		t.Fatalf("unexpected error: got %v want %v", err, ErrHookTimeout)
+	}
+	want := []string{"env", "ZEN_TARGETS=", "ZEN_APP=", "ZEN_PROFILE=default", "ZEN_DRY_RUN=0", "sh", "-c", "sleep 60"}
+	if !reflect.DeepEqual(executor.args, want) {
+		t.Fatalf("unexpected args: got %q want %q", executor.args, want)
+	}
+}
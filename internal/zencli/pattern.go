@@ -0,0 +1,167 @@
+package zencli
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// regexPrefix marks an allow or disallow entry as a regular expression.
+	regexPrefix = "re:"
+	// bundlePrefix marks an entry matching the macOS bundle identifier, which
+	// survives renames and localized app names.
+	bundlePrefix = "bundle:"
+	// namePrefix marks an entry as a plain app name, so *, ? and [ in it
+	// are not read as wildcards.
+	namePrefix = "name:"
+)
+
+type patternKind int
+
+const (
+	patternLiteral patternKind = iota
+	patternGlob
+	patternRegex
+	patternBundle
+)
+
+// AppPattern is one allowedApps or disallowedApps entry. Entries are plain
+// app names, globs such as "Microsoft *", regular expressions prefixed with
+// "re:", bundle identifiers prefixed with "bundle:", which may also use glob
+// wildcards, or literal names prefixed with "name:". All of them match
+// case-insensitively.
+type AppPattern struct {
+	Raw  string
+	kind patternKind
+	expr string
+	re   *regexp.Regexp
+	// warning explains an entry that looked like a glob but is matched as
+	// a plain name.
+	warning string
+}
+
+// ParsePattern parses and validates a single entry.
+func ParsePattern(raw string) (AppPattern, error) {
+	entry := strings.TrimSpace(raw)
+	if expr, ok := strings.CutPrefix(entry, regexPrefix); ok {
+		if strings.TrimSpace(expr) == "" {
+			return AppPattern{}, fmt.Errorf("invalid pattern %q: empty regular expression", raw)
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return AppPattern{}, fmt.Errorf("invalid pattern %q: %w", raw, err)
+		}
+		return AppPattern{Raw: entry, kind: patternRegex, re: re}, nil
+	}
+
+	if id, ok := strings.CutPrefix(entry, bundlePrefix); ok {
+		expr := strings.ToLower(strings.TrimSpace(id))
+		if expr == "" {
+			return AppPattern{}, fmt.Errorf("invalid pattern %q: empty bundle identifier", raw)
+		}
+		if _, err := path.Match(expr, ""); err != nil {
+			return AppPattern{}, fmt.Errorf("invalid pattern %q: malformed glob", raw)
+		}
+		return AppPattern{Raw: entry, kind: patternBundle, expr: expr}, nil
+	}
+
+	if name, ok := strings.CutPrefix(entry, namePrefix); ok {
+		expr := strings.ToLower(strings.TrimSpace(name))
+		if expr == "" {
+			return AppPattern{}, fmt.Errorf("invalid pattern %q: empty app name", raw)
+		}
+		return AppPattern{Raw: entry, kind: patternLiteral, expr: expr}, nil
+	}
+
+	expr := strings.ToLower(entry)
+	if strings.ContainsAny(entry, "*?[") {
+		if _, err := path.Match(expr, ""); err == nil {
+			return AppPattern{Raw: entry, kind: patternGlob, expr: expr}, nil
+		}
+		// Names such as "Foo [Beta" were plain names before globs existed;
+		// keep matching them that way rather than breaking the config.
+		warning := fmt.Sprintf("pattern %q is not a valid glob and only matches that exact name; write %q to make that explicit", entry, namePrefix+entry)
+		return AppPattern{Raw: entry, kind: patternLiteral, expr: expr, warning: warning}, nil
+	}
+
+	return AppPattern{Raw: entry, kind: patternLiteral, expr: expr}, nil
+}
+
+// ValidatePatterns reports every invalid entry of apps.
+func ValidatePatterns(apps []string) error {
+	var errs []error
+	for _, app := range apps {
+		if _, err := ParsePattern(app); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PatternWarnings returns a warning for every valid entry of apps that is
+// probably not doing what its author meant.
+func PatternWarnings(apps []string) []string {
+	var warnings []string
+	for _, app := range apps {
+		if pattern, err := ParsePattern(app); err == nil && pattern.warning != "" {
+			warnings = append(warnings, pattern.warning)
+		}
+	}
+	return warnings
+}
+
+// Match reports whether app is matched by the pattern. Names are compared
+// without surrounding spaces, as entries are. A glob also matches the app
+// named exactly like it, so an existing entry such as "Foo [Beta]" keeps
+// matching that app. Bundle patterns never match apps without a bundle
+// identifier.
+func (p AppPattern) Match(app AppInfo) bool {
+	name := strings.TrimSpace(app.Name)
+	switch p.kind {
+	case patternRegex:
+		return p.re.MatchString(name)
+	case patternGlob:
+		lower := strings.ToLower(name)
+		if lower == p.expr {
+			return true
+		}
+		matched, _ := path.Match(p.expr, lower)
+		return matched
+	case patternBundle:
+		if app.BundleID == "" {
+			return false
+		}
+		matched, _ := path.Match(p.expr, strings.ToLower(app.BundleID))
+		return matched
+	}
+	return strings.ToLower(name) == p.expr
+}
+
+// compilePatterns parses apps, skipping blank and invalid entries; callers
+// validate user input before it gets here.
+func compilePatterns(apps []string) []AppPattern {
+	patterns := make([]AppPattern, 0, len(apps))
+	for _, app := range apps {
+		if strings.TrimSpace(app) == "" {
+			continue
+		}
+		pattern, err := ParsePattern(app)
+		if err != nil {
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+func firstMatch(patterns []AppPattern, app AppInfo) (AppPattern, bool) {
+	for _, pattern := range patterns {
+		if pattern.Match(app) {
+			return pattern, true
+		}
+	}
+	return AppPattern{}, false
+}
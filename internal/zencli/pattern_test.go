@@ -0,0 +1,169 @@
+package zencli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePatternMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		app     string
+		want    bool
+	}{
+		{"Slack", "slack", true},
+		{"Slack", "Slack Helper", false},
+		{"Microsoft *", "Microsoft Word", true},
+		{"microsoft *", "Microsoft Excel", true},
+		{"Microsoft *", "Microsoft", false},
+		{"JetBrains*", "JetBrains Toolbox", true},
+		{"Code?", "Codex", true},
+		{"re:^Xcode.*", "Xcode-beta", true},
+		{"re:^xcode$", "Xcode", true},
+		{"re:^Xcode$", "Xcode-beta", false},
+		{"Foo [Beta]", "Foo [Beta]", true},
+		{"Foo [Beta]", "Foo B", true},
+		{"name:Foo [Beta]", "foo [beta]", true},
+		{"name:Foo [Beta]", "Foo B", false},
+		{"name:Code?", "Codex", false},
+		{"Foo [Beta", "Foo [Beta", true},
+		{`Foo \[Beta\]`, "Foo [Beta]", true},
+	}
+
+	for _, tc := range cases {
+		pattern, err := ParsePattern(tc.pattern)
+		if err != nil {
+			t.Fatalf("ParsePattern(%q): unexpected error %v", tc.pattern, err)
+		}
+		if got := pattern.Match(AppInfo{Name: tc.app}); got != tc.want {
+			t.Fatalf("%q matching %q: got %v want %v", tc.pattern, tc.app, got, tc.want)
+		}
+	}
+}
+
+func TestParsePatternRejectsInvalidEntries(t *testing.T) {
+	for _, raw := range []string{"re:(", "re:", "name: ", "re:[a-"} {
+		if _, err := ParsePattern(raw); err == nil {
+			t.Fatalf("ParsePattern(%q): expected error, got nil", raw)
+		}
+	}
+}
+
+func TestValidatePatternsReportsEveryEntry(t *testing.T) {
+	err := ValidatePatterns([]string{"Slack", "re:(", "Teams [", "Microsoft *", "re:"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	want := "invalid pattern \"re:(\": error parsing regexp: missing closing ): `(?i)(`\n" +
+		"invalid pattern \"re:\": empty regular expression"
+	if err.Error() != want {
+		t.Fatalf("unexpected error: got %q want %q", err.Error(), want)
+	}
+}
+
+func TestPatternWarningsFlagsMalformedGlobs(t *testing.T) {
+	got := PatternWarnings([]string{"Slack", "Teams [", "Microsoft *", "name:Teams ["})
+	want := []string{`pattern "Teams [" is not a valid glob and only matches that exact name; write "name:Teams [" to make that explicit`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected warnings: got %q want %q", got, want)
+	}
+}
+
+func TestTargetAppsFromRunningWithPatterns(t *testing.T) {
+	running := []string{"Microsoft Word", "Microsoft Teams", "JetBrains Toolbox", "Xcode", "Slack"}
+	opts := Options{
+		AllowedApps:           []string{"Microsoft *", "re:^(jetbrains|xcode)"},
+		DisallowedApps:        []string{"Microsoft Teams"},
+		ReplaceDefaultAllowed: true,
+	}
+
+	got := AppNames(targetAppsFromRunning(appInfos(running...), opts))
+	want := []string{"Microsoft Teams", "Slack"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets: got %v want %v", got, want)
+	}
+}
+
+func TestResolveAllowedAppsDisallowGlobRemovesNames(t *testing.T) {
+	opts := Options{
+		AllowedApps:           []string{"Microsoft Word", "Microsoft *", "Slack"},
+		DisallowedApps:        []string{"Microsoft W*"},
+		ReplaceDefaultAllowed: true,
+	}
+
+	got := resolveAllowedApps(opts)
+	want := []string{"Microsoft *", "Slack"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected allow-list: got %v want %v", got, want)
+	}
+}
+
+func TestMatchRunningAppsReportsPatterns(t *testing.T) {
+	running := []string{"Slack", "Microsoft Word", "zen", "gnome-shell", "Microsoft Teams"}
+	opts := Options{
+		AllowedApps:           []string{"Microsoft *"},
+		DisallowedApps:        []string{"re:teams$"},
+		ReplaceDefaultAllowed: true,
+	}
+
+	got := matchRunningApps(appInfos(running...), opts, "gnome-shell")
+	want := []AppMatch{
+		{App: "Microsoft Teams", Status: MatchTarget, Pattern: "re:teams$"},
+		{App: "Microsoft Word", Status: MatchAllowed, Pattern: "Microsoft *"},
+		{App: "Slack", Status: MatchTarget},
+		{App: "gnome-shell", Status: MatchProtected},
+		{App: "zen", Status: MatchProtected},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected matches: got %+v want %+v", got, want)
+	}
+}
+
+func TestBundlePatternMatchesBundleIdentifier(t *testing.T) {
+	slack := AppInfo{Name: "Slack", BundleID: "com.tinyspeck.slackmacgap", PID: 812}
+
+	exact, err := ParsePattern("bundle:com.tinyspeck.slackmacgap")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !exact.Match(slack) {
+		t.Fatal("expected bundle pattern to match")
+	}
+	if exact.Match(AppInfo{Name: "com.tinyspeck.slackmacgap"}) {
+		t.Fatal("expected bundle pattern not to match an app without a bundle identifier")
+	}
+
+	wildcard, err := ParsePattern("bundle:com.microsoft.*")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !wildcard.Match(AppInfo{Name: "Microsoft Word", BundleID: "com.microsoft.Word"}) || wildcard.Match(slack) {
+		t.Fatal("unexpected bundle glob result")
+	}
+
+	if _, err := ParsePattern("bundle: "); err == nil {
+		t.Fatal("expected error for empty bundle identifier, got nil")
+	}
+}
+
+func TestTargetAppsFromRunningWithBundlePatterns(t *testing.T) {
+	running := []AppInfo{
+		{Name: "Slack", BundleID: "com.tinyspeck.slackmacgap", PID: 812},
+		{Name: "メモ", BundleID: "com.apple.Notes", PID: 640},
+		{Name: "Safari", BundleID: "com.apple.Safari", PID: 501},
+	}
+	opts := Options{
+		AllowedApps:           []string{"bundle:com.apple.*"},
+		DisallowedApps:        []string{"bundle:com.apple.Safari"},
+		ReplaceDefaultAllowed: true,
+	}
+
+	got := targetAppsFromRunning(running, opts)
+	want := []AppInfo{
+		{Name: "Safari", BundleID: "com.apple.Safari", PID: 501},
+		{Name: "Slack", BundleID: "com.tinyspeck.slackmacgap", PID: 812},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets: got %+v want %+v", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package zencli
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrNotRunning is returned by Platform.ForceQuit when the app had already
+// exited, which callers treat as success.
+var ErrNotRunning = errors.New("app is not running")
+
+// AppInfo identifies a running app. BundleID is only known on macOS, and PID
+// is zero when the platform could not resolve it.
+type AppInfo struct {
+	Name     string
+	BundleID string
+	PID      int
+}
+
+// AppNames returns the names of apps.
+func AppNames(apps []AppInfo) []string {
+	names := make([]string, 0, len(apps))
+	for _, app := range apps {
+		names = append(names, app.Name)
+	}
+	return names
+}
+
+// Platform is the OS-specific backend used to discover and close GUI apps.
+type Platform interface {
+	// RunningApps lists the running apps that own a user-facing UI.
+	RunningApps(executor Executor) ([]AppInfo, error)
+	// Quit asks the app to exit gracefully.
+	Quit(executor Executor, app AppInfo) error
+	// IsRunning reports whether any process of the app is still alive.
+	IsRunning(executor Executor, app AppInfo) (bool, error)
+	// Terminate sends SIGTERM and returns ErrNotRunning when the app had
+	// already exited.
+	Terminate(executor Executor, app AppInfo) error
+	// ForceQuit sends SIGKILL and returns ErrNotRunning when there was
+	// nothing left to kill.
+	ForceQuit(executor Executor, app AppInfo) error
+	// Launch starts the app again, for example after a focus session.
+	Launch(executor Executor, app AppInfo) error
+	// ProtectedApps lists apps that are never quit on this platform, such as
+	// the desktop shell.
+	ProtectedApps() []string
+}
+
+// platformForOS selects the backend for the running OS. Tests replace it to
+// exercise the package without a real desktop session.
+var platformForOS = func() (Platform, error) {
+	return PlatformFor(runtime.GOOS)
+}
+
+// PlatformFor returns the backend for goos, or ErrUnsupportedOS.
+func PlatformFor(goos string) (Platform, error) {
+	switch goos {
+	case "darwin":
+		return macPlatform{}, nil
+	case "linux":
+		return linuxPlatform{}, nil
+	}
+	return nil, ErrUnsupportedOS
+}
+
+// The helpers below address processes by exact name through pgrep and pkill,
+// which behave the same on macOS and Linux.
+
+func processRunning(executor Executor, appName string) (bool, error) {
+	out, err := executor.Run("pgrep", "-x", appName)
+	if err != nil {
+		if strings.TrimSpace(string(out)) == "" {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check %s: %w: %s", appName, err, strings.TrimSpace(string(out)))
+	}
+	return true, nil
+}
+
+func terminateProcess(executor Executor, appName string) error {
+	matched, err := signalProcess(executor, "TERM", appName)
+	if err != nil {
+		return fmt.Errorf("failed to terminate %s: %w", appName, err)
+	}
+	if !matched {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+func killProcess(executor Executor, appName string) error {
+	matched, err := signalProcess(executor, "KILL", appName)
+	if err != nil {
+		return fmt.Errorf("failed to force close %s: %w", appName, err)
+	}
+	if !matched {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+// signalProcess sends signal to processes named appName and reports whether
+// any matched. pkill exits non-zero without output when nothing matched.
+func signalProcess(executor Executor, signal string, appName string) (bool, error) {
+	out, err := executor.Run("pkill", "-"+signal, "-x", appName)
+	if err != nil {
+		if strings.TrimSpace(string(out)) == "" {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return true, nil
+}
+
+// The PID helpers address a single process, which is exact for macOS apps
+// where one process is one app.
+
+func pidRunning(executor Executor, pid int) (bool, error) {
+	out, err := executor.Run("ps", "-o", "pid=", "-p", strconv.Itoa(pid))
+	if err != nil {
+		if strings.TrimSpace(string(out)) == "" {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check pid %d: %w: %s", pid, err, strings.TrimSpace(string(out)))
+	}
+	return true, nil
+}
+
+func signalPID(executor Executor, signal string, app AppInfo) error {
+	out, err := executor.Run("kill", "-"+signal, strconv.Itoa(app.PID))
+	if err != nil {
+		if strings.Contains(string(out), "No such process") {
+			return ErrNotRunning
+		}
+		verb := "terminate"
+		if signal == "KILL" {
+			verb = "force close"
+		}
+		return fmt.Errorf("failed to %s %s: %w: %s", verb, app.Name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package zencli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// linuxDesktopApps are desktop shell and terminal processes that own windows
+// but must never be closed by zen-cli.
+var linuxDesktopApps = []string{
+	"Xorg",
+	"Xwayland",
+	"gnome-shell",
+	"plasmashell",
+	"kwin_x11",
+	"kwin_wayland",
+	"xfdesktop",
+	"xfce4-panel",
+	"mate-panel",
+	"cinnamon",
+	"lxpanel",
+	"pcmanfm",
+	"nemo-desktop",
+	"gnome-terminal-",
+	"konsole",
+	"xfce4-terminal",
+	"tilix",
+	"terminator",
+	"xterm",
+	"kitty",
+	"alacritty",
+	"wezterm-gui",
+}
+
+// linuxPlatform discovers apps as the processes owning top-level windows
+// reported by wmctrl. Without a portable "quit" request, the graceful quit
+// is SIGTERM, the signal well-behaved X11 apps handle by saving and exiting.
+type linuxPlatform struct{}
+
+func (linuxPlatform) RunningApps(executor Executor) ([]AppInfo, error) {
+	out, err := executor.Run("wmctrl", "-lp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	pids := parseWindowPIDs(string(out))
+	if len(pids) == 0 {
+		return nil, nil
+	}
+
+	// ps exits non-zero when any of the PIDs is gone, which happens whenever
+	// a window closes between the two calls. The processes it did list are
+	// still valid, and no output at all means every one of them exited.
+	out, err = executor.Run("ps", "-o", "pid=,comm=", "-p", strings.Join(pids, ","))
+	apps := parseProcessList(string(out))
+	if err != nil && len(apps) == 0 && strings.TrimSpace(string(out)) != "" {
+		return nil, fmt.Errorf("failed to resolve window processes: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return apps, nil
+}
+
+// The Linux backend signals apps by process name rather than PID, since an
+// app such as a browser runs many processes that all need to exit.
+
+func (linuxPlatform) Quit(executor Executor, app AppInfo) error {
+	if _, err := signalProcess(executor, "TERM", app.Name); err != nil {
+		return fmt.Errorf("failed to quit %s: %w", app.Name, err)
+	}
+	return nil
+}
+
+func (linuxPlatform) IsRunning(executor Executor, app AppInfo) (bool, error) {
+	return processRunning(executor, app.Name)
+}
+
+func (linuxPlatform) Terminate(executor Executor, app AppInfo) error {
+	return terminateProcess(executor, app.Name)
+}
+
+func (linuxPlatform) ForceQuit(executor Executor, app AppInfo) error {
+	return killProcess(executor, app.Name)
+}
+
+// Launch starts the app detached from zen-cli so the executor does not wait
+// for it to exit.
+func (linuxPlatform) Launch(executor Executor, app AppInfo) error {
+	if out, err := executor.Run("setsid", "-f", app.Name); err != nil {
+		return fmt.Errorf("failed to launch %s: %w: %s", app.Name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (linuxPlatform) ProtectedApps() []string {
+	return linuxDesktopApps
+}
+
+// parseWindowPIDs extracts the unique owner PIDs from `wmctrl -lp` output,
+// whose columns are window id, desktop, pid, host and title.
+func parseWindowPIDs(raw string) []string {
+	var pids []string
+	seen := make(map[string]struct{})
+	for _, line := range strings.Split(raw, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[2])
+		if err != nil || pid <= 0 {
+			continue
+		}
+		if _, ok := seen[fields[2]]; ok {
+			continue
+		}
+		seen[fields[2]] = struct{}{}
+		pids = append(pids, fields[2])
+	}
+	return pids
+}
+
+// parseProcessList parses `ps -o pid=,comm=` output, keeping the first PID
+// of every process name.
+func parseProcessList(raw string) []AppInfo {
+	var apps []AppInfo
+	seen := make(map[string]struct{})
+	for _, line := range strings.Split(raw, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		// comm may contain spaces; everything after the PID is the name.
+		name := strings.TrimSpace(strings.TrimSpace(line)[len(fields[0]):])
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		apps = append(apps, AppInfo{Name: name, PID: pid})
+	}
+	return apps
+}
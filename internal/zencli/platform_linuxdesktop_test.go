@@ -0,0 +1,181 @@
+package zencli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseWindowPIDs(t *testing.T) {
+	input := "0x01e00003  0 2345   host Mozilla Firefox\n" +
+		"0x01e00007  0 2345   host Another Firefox window\n" +
+		"0x02400004 -1 0      host Desktop\n" +
+		"0x03a00001  1 4242   host Slack | general\n"
+
+	got := parseWindowPIDs(input)
+	want := []string{"2345", "4242"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected pids: got %v want %v", got, want)
+	}
+}
+
+func TestLinuxPlatformRunningApps(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"wmctrl|-lp":                {output: []byte("0x01 0 10 host Firefox\n0x02 0 20 host Slack\n0x03 0 10 host Firefox\n")},
+			"ps|-o|pid=,comm=|-p|10,20": {output: []byte("   10 firefox\n   20 slack\n")},
+		},
+	}
+
+	got, err := (linuxPlatform{}).RunningApps(mock)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := []AppInfo{{Name: "firefox", PID: 10}, {Name: "slack", PID: 20}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected apps: got %+v want %+v", got, want)
+	}
+}
+
+func TestLinuxPlatformRunningAppsSkipsExitedProcesses(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"wmctrl|-lp":                {output: []byte("0x01 0 10 host Firefox\n0x02 0 20 host Slack\n")},
+			"ps|-o|pid=,comm=|-p|10,20": {output: []byte("   20 slack\n"), err: errors.New("exit status 1")},
+		},
+	}
+
+	got, err := (linuxPlatform{}).RunningApps(mock)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := []AppInfo{{Name: "slack", PID: 20}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected apps: got %+v want %+v", got, want)
+	}
+}
+
+func TestLinuxPlatformRunningAppsAllProcessesExited(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"wmctrl|-lp":             {output: []byte("0x01 0 10 host Firefox\n")},
+			"ps|-o|pid=,comm=|-p|10": {err: errors.New("exit status 1")},
+		},
+	}
+
+	got, err := (linuxPlatform{}).RunningApps(mock)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no apps, got %+v", got)
+	}
+}
+
+func TestLinuxPlatformRunningAppsPsFails(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"wmctrl|-lp":             {output: []byte("0x01 0 10 host Firefox\n")},
+			"ps|-o|pid=,comm=|-p|10": {output: []byte("ps: unknown option"), err: errors.New("exit status 1")},
+		},
+	}
+
+	if _, err := (linuxPlatform{}).RunningApps(mock); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseProcessList(t *testing.T) {
+	input := "  10 firefox\n  11 firefox\n 300 Web Content\n\nbogus line\n"
+
+	got := parseProcessList(input)
+	want := []AppInfo{{Name: "firefox", PID: 10}, {Name: "Web Content", PID: 300}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected processes: got %+v want %+v", got, want)
+	}
+}
+
+func TestLinuxPlatformRunningAppsWmctrlMissing(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"wmctrl|-lp": {output: []byte("wmctrl: not found"), err: errors.New("exit status 127")},
+		},
+	}
+
+	if _, err := (linuxPlatform{}).RunningApps(mock); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestLinuxPlatformQuitSendsSIGTERM(t *testing.T) {
+	mock := &mockExecutor{}
+
+	if err := (linuxPlatform{}).Quit(mock, AppInfo{Name: "slack"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	wantCalls := []string{"pkill|-TERM|-x|slack"}
+	if !reflect.DeepEqual(mock.calls, wantCalls) {
+		t.Fatalf("unexpected calls: got %v want %v", mock.calls, wantCalls)
+	}
+}
+
+func TestLinuxPlatformQuitNotRunning(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"pkill|-TERM|-x|slack": {err: errors.New("exit status 1")},
+		},
+	}
+
+	if err := (linuxPlatform{}).Quit(mock, AppInfo{Name: "slack"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestLinuxPlatformIsRunning(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"pgrep|-x|slack": {err: errors.New("exit status 1")},
+		},
+	}
+
+	running, err := (linuxPlatform{}).IsRunning(mock, AppInfo{Name: "slack"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if running {
+		t.Fatal("expected slack to be reported as exited")
+	}
+
+	running, err = (linuxPlatform{}).IsRunning(mock, AppInfo{Name: "firefox"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !running {
+		t.Fatal("expected firefox to be reported as running")
+	}
+}
+
+func TestLinuxPlatformForceQuitNotRunning(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"pkill|-KILL|-x|slack": {err: errors.New("exit status 1")},
+		},
+	}
+
+	if err := (linuxPlatform{}).ForceQuit(mock, AppInfo{Name: "slack"}); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning, got %v", err)
+	}
+}
+
+func TestLinuxPlatformForceQuit(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"pkill|-KILL|-x|slack": {output: []byte("Operation not permitted"), err: errors.New("exit status 3")},
+		},
+	}
+
+	if err := (linuxPlatform{}).ForceQuit(mock, AppInfo{Name: "slack"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
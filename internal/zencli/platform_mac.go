@@ -0,0 +1,129 @@
+package zencli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// macFieldSeparator and macRecordSeparator are the ASCII unit and record
+	// separators. Unlike commas, tabs or newlines they cannot appear in app
+	// names, so the listing never splits a name.
+	macFieldSeparator  = "\x1f"
+	macRecordSeparator = "\x1e"
+)
+
+// macListScript prints one record per foreground app: name, bundle
+// identifier and PID. Apps without a bundle print an empty identifier.
+const macListScript = `set fieldSep to character id 31
+set recordSep to character id 30
+set output to ""
+tell application "System Events"
+	repeat with proc in (every application process whose background only is false)
+		set bundleID to bundle identifier of proc
+		if bundleID is missing value then set bundleID to ""
+		set output to output & (name of proc) & fieldSep & bundleID & fieldSep & (unix id of proc) & recordSep
+	end repeat
+end tell
+return output`
+
+// macPlatform drives apps through System Events via osascript.
+type macPlatform struct{}
+
+func (macPlatform) RunningApps(executor Executor) ([]AppInfo, error) {
+	out, err := executor.Run("osascript", "-e", macListScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list running apps: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return parseAppInfoList(string(out)), nil
+}
+
+// Quit addresses the app by bundle identifier when known, since display
+// names can be localized or differ from the process name.
+func (macPlatform) Quit(executor Executor, app AppInfo) error {
+	target := "application " + appleScriptString(app.Name)
+	if app.BundleID != "" {
+		target = "application id " + appleScriptString(app.BundleID)
+	}
+	quitScript := fmt.Sprintf(`tell %s to quit`, target)
+	if out, err := executor.Run("osascript", "-e", quitScript); err != nil {
+		return fmt.Errorf("failed to quit %s: %w: %s", app.Name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (macPlatform) IsRunning(executor Executor, app AppInfo) (bool, error) {
+	if app.PID > 0 {
+		return pidRunning(executor, app.PID)
+	}
+	return processRunning(executor, app.Name)
+}
+
+func (macPlatform) Terminate(executor Executor, app AppInfo) error {
+	if app.PID > 0 {
+		return signalPID(executor, "TERM", app)
+	}
+	return terminateProcess(executor, app.Name)
+}
+
+func (macPlatform) ForceQuit(executor Executor, app AppInfo) error {
+	if app.PID > 0 {
+		return signalPID(executor, "KILL", app)
+	}
+	return killProcess(executor, app.Name)
+}
+
+// Launch opens the app by bundle ID when known, so renamed or localized apps
+// are still found, and by name otherwise.
+func (macPlatform) Launch(executor Executor, app AppInfo) error {
+	args := []string{"-a", app.Name}
+	if app.BundleID != "" {
+		args = []string{"-b", app.BundleID}
+	}
+	if out, err := executor.Run("open", args...); err != nil {
+		return fmt.Errorf("failed to launch %s: %w: %s", app.Name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (macPlatform) ProtectedApps() []string {
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	escaped := strings.ReplaceAll(s, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	return `"` + escaped + `"`
+}
+
+// parseAppInfoList parses the output of macListScript. Names are kept
+// exactly as reported, including surrounding spaces, because pkill -x and
+// AppleScript need the exact name. Records with a missing or malformed PID
+// keep the app with PID zero.
+func parseAppInfoList(raw string) []AppInfo {
+	// osascript terminates the result with a newline.
+	raw = strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+
+	var apps []AppInfo
+	for _, record := range strings.Split(raw, macRecordSeparator) {
+		fields := strings.Split(record, macFieldSeparator)
+		name := fields[0]
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+
+		app := AppInfo{Name: name}
+		if len(fields) > 1 {
+			app.BundleID = strings.TrimSpace(fields[1])
+		}
+		if len(fields) > 2 {
+			if pid, err := strconv.Atoi(strings.TrimSpace(fields[2])); err == nil && pid > 0 {
+				app.PID = pid
+			}
+		}
+		apps = append(apps, app)
+	}
+	return apps
+}
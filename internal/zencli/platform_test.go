@@ -0,0 +1,282 @@
+package zencli
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type fakePlatform struct {
+	mu         sync.Mutex
+	running    []string
+	scans      [][]string
+	quitErrs   map[string]error
+	launchErrs map[string]error
+	// listErrs fail the next listings, one entry per call; nil succeeds.
+	listErrs []error
+	// lingering is the number of IsRunning checks an app survives after
+	// being asked to quit.
+	lingering       map[string]int
+	quit            []string
+	terminated      []string
+	forceQuit       []string
+	launched        []string
+	launchedBundles []string
+}
+
+// RunningApps returns the next entry of scans when set, so consecutive
+// listings can differ, and running otherwise.
+func (f *fakePlatform) RunningApps(Executor) ([]AppInfo, error) {
+	if len(f.listErrs) > 0 {
+		err := f.listErrs[0]
+		f.listErrs = f.listErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	running := f.running
+	if len(f.scans) > 0 {
+		running = f.scans[0]
+		f.scans = f.scans[1:]
+	}
+	return appInfos(running...), nil
+}
+
+func appInfos(names ...string) []AppInfo {
+	if names == nil {
+		return nil
+	}
+	apps := make([]AppInfo, 0, len(names))
+	for _, name := range names {
+		apps = append(apps, AppInfo{Name: name})
+	}
+	return apps
+}
+
+func (f *fakePlatform) Quit(_ Executor, app AppInfo) error {
+	appName := app.Name
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.quit = append(f.quit, appName)
+	return f.quitErrs[appName]
+}
+
+func (f *fakePlatform) IsRunning(_ Executor, app AppInfo) (bool, error) {
+	appName := app.Name
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lingering[appName] > 0 {
+		f.lingering[appName]--
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *fakePlatform) Terminate(_ Executor, app AppInfo) error {
+	appName := app.Name
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.terminated = append(f.terminated, appName)
+	return nil
+}
+
+func (f *fakePlatform) ForceQuit(_ Executor, app AppInfo) error {
+	appName := app.Name
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forceQuit = append(f.forceQuit, appName)
+	return nil
+}
+
+func (f *fakePlatform) Launch(_ Executor, app AppInfo) error {
+	f.launched = append(f.launched, app.Name)
+	f.launchedBundles = append(f.launchedBundles, app.BundleID)
+	return f.launchErrs[app.Name]
+}
+
+func (f *fakePlatform) ProtectedApps() []string {
+	return []string{"gnome-shell"}
+}
+
+func useFakePlatform(t *testing.T, platform Platform) {
+	t.Helper()
+	original := platformForOS
+	platformForOS = func() (Platform, error) {
+		return platform, nil
+	}
+	t.Cleanup(func() {
+		platformForOS = original
+	})
+}
+
+func TestPlatformForUnsupportedOS(t *testing.T) {
+	if _, err := PlatformFor("windows"); !errors.Is(err, ErrUnsupportedOS) {
+		t.Fatalf("expected ErrUnsupportedOS, got %v", err)
+	}
+}
+
+func TestPlatformForDarwin(t *testing.T) {
+	platform, err := PlatformFor("darwin")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, ok := platform.(macPlatform); !ok {
+		t.Fatalf("unexpected platform: %T", platform)
+	}
+}
+
+func TestPlatformForLinux(t *testing.T) {
+	platform, err := PlatformFor("linux")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, ok := platform.(linuxPlatform); !ok {
+		t.Fatalf("unexpected platform: %T", platform)
+	}
+}
+
+func TestExecuteWithOptionsUsesPlatform(t *testing.T) {
+	fake := &fakePlatform{
+		running:   []string{"Slack", "Terminal", "gnome-shell", "Safari"},
+		lingering: map[string]int{"Safari": 1, "Slack": 1},
+	}
+	useFakePlatform(t, fake)
+
+	results, err := ExecuteWithOptions(&mockExecutor{}, Options{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := []string{"Safari", "Slack"}
+	if got := ClosedApps(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected killed apps: got %v want %v", got, want)
+	}
+	if !reflect.DeepEqual(fake.quit, want) {
+		t.Fatalf("unexpected quit calls: got %v want %v", fake.quit, want)
+	}
+	if !reflect.DeepEqual(fake.forceQuit, want) {
+		t.Fatalf("unexpected force quit calls: got %v want %v", fake.forceQuit, want)
+	}
+}
+
+func TestExecuteWithOptionsContinuesAfterQuitError(t *testing.T) {
+	quitErr := errors.New("quit refused")
+	fake := &fakePlatform{
+		running:   []string{"Music", "Safari", "Slack"},
+		quitErrs:  map[string]error{"Music": quitErr},
+		lingering: map[string]int{"Safari": 1, "Slack": 1},
+	}
+	useFakePlatform(t, fake)
+
+	results, err := ExecuteWithOptions(&mockExecutor{}, Options{})
+	if !errors.Is(err, quitErr) {
+		t.Fatalf("expected joined quit error, got %v", err)
+	}
+
+	want := []QuitResult{
+		{App: "Music", Graceful: StepFailed, Terminate: StepSkipped, ForceKill: StepSkipped, Err: quitErr},
+		{App: "Safari", Graceful: StepOK, Terminate: StepSkipped, ForceKill: StepOK},
+		{App: "Slack", Graceful: StepOK, Terminate: StepSkipped, ForceKill: StepOK},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: got %+v want %+v", results, want)
+	}
+	if !reflect.DeepEqual(fake.forceQuit, []string{"Safari", "Slack"}) {
+		t.Fatalf("expected the failed app not to be killed, got %v", fake.forceQuit)
+	}
+}
+
+func TestExecuteWithOptionsJoinsAllErrors(t *testing.T) {
+	fake := &fakePlatform{
+		running: []string{"Music", "Slack"},
+		quitErrs: map[string]error{
+			"Music": errors.New("music refused"),
+			"Slack": errors.New("slack refused"),
+		},
+	}
+	useFakePlatform(t, fake)
+
+	results, err := ExecuteWithOptions(&mockExecutor{}, Options{})
+	for _, app := range []string{"Music", "Slack"} {
+		if !errors.Is(err, fake.quitErrs[app]) {
+			t.Fatalf("expected error for %s in %v", app, err)
+		}
+	}
+	if len(ClosedApps(results)) != 0 {
+		t.Fatalf("expected no closed apps, got %v", ClosedApps(results))
+	}
+}
+
+func TestClosedAppInfosKeepsBundleIDs(t *testing.T) {
+	results := []QuitResult{
+		{App: "Safari", BundleID: "com.apple.Safari"},
+		{App: "Slack", Err: ErrStillRunning},
+		{App: "Music"},
+	}
+	want := []AppInfo{{Name: "Safari", BundleID: "com.apple.Safari"}, {Name: "Music"}}
+	if got := ClosedAppInfos(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected closed apps: got %v want %v", got, want)
+	}
+}
+
+func TestRelaunchAppsContinuesPastFailures(t *testing.T) {
+	fake := &fakePlatform{launchErrs: map[string]error{"Slack": errors.New("not installed")}}
+	useFakePlatform(t, fake)
+
+	apps := []AppInfo{{Name: "Safari", BundleID: "com.apple.Safari"}, {Name: "Slack"}, {Name: "Music"}}
+	got, err := RelaunchApps(&mockExecutor{}, apps)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !reflect.DeepEqual(got, []string{"Safari", "Music"}) {
+		t.Fatalf("unexpected launched apps: %v", got)
+	}
+	if !reflect.DeepEqual(fake.launched, []string{"Safari", "Slack", "Music"}) {
+		t.Fatalf("unexpected launch calls: %v", fake.launched)
+	}
+	if !reflect.DeepEqual(fake.launchedBundles, []string{"com.apple.Safari", "", ""}) {
+		t.Fatalf("unexpected launched bundle IDs: %v", fake.launchedBundles)
+	}
+}
+
+func TestMacPlatformLaunch(t *testing.T) {
+	mock := &mockExecutor{}
+	if err := (macPlatform{}).Launch(mock, AppInfo{Name: "Slack"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(mock.calls, []string{"open|-a|Slack"}) {
+		t.Fatalf("unexpected calls: %v", mock.calls)
+	}
+}
+
+func TestMacPlatformLaunchByBundleID(t *testing.T) {
+	mock := &mockExecutor{}
+	app := AppInfo{Name: "Slack", BundleID: "com.tinyspeck.slackmacgap"}
+	if err := (macPlatform{}).Launch(mock, app); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(mock.calls, []string{"open|-b|com.tinyspeck.slackmacgap"}) {
+		t.Fatalf("unexpected calls: %v", mock.calls)
+	}
+}
+
+func TestMacPlatformRunningApps(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"osascript|-e|" + macListScript: {output: []byte(record("Safari", "com.apple.Safari", "501") + record("Slack", "com.tinyspeck.slackmacgap", "812") + "\n")},
+		},
+	}
+
+	got, err := macPlatform{}.RunningApps(mock)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := []AppInfo{
+		{Name: "Safari", BundleID: "com.apple.Safari", PID: 501},
+		{Name: "Slack", BundleID: "com.tinyspeck.slackmacgap", PID: 812},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected apps: got %+v want %+v", got, want)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package zencli
+
+import "os/exec"
+
+// zen-cli only runs on macOS and Linux; elsewhere only the direct child is
+// killed.
+func startProcessGroup(*exec.Cmd) {}
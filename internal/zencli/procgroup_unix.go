@@ -0,0 +1,17 @@
+//go:build darwin || linux
+
+package zencli
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startProcessGroup runs cmd in its own process group and makes cancelling
+// it kill the whole group, so children a shell started do not outlive it.
+func startProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
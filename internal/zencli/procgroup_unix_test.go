@@ -0,0 +1,20 @@
+//go:build darwin || linux
+
+package zencli
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunHookKillsChildrenOnTimeout(t *testing.T) {
+	start := time.Now()
+	_, err := RunHook(OSExecutor{}, "sleep 5; echo late", HookEnv{}, 200*time.Millisecond)
+	if !errors.Is(err, ErrHookTimeout) {
+		t.Fatalf("unexpected error: got %v want %v", err, ErrHookTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("hook outlived its timeout: returned after %s", elapsed)
+	}
+}
@@ -0,0 +1,76 @@
+package zencli
+
+import (
+	"errors"
+	"strings"
+)
+
+// RestoreOutcome describes what restoring one app did.
+type RestoreOutcome string
+
+const (
+	RestoreLaunched       RestoreOutcome = "launched"
+	RestoreAlreadyRunning RestoreOutcome = "already-running"
+	RestoreWouldLaunch    RestoreOutcome = "would-launch"
+	RestoreFailed         RestoreOutcome = "failed"
+)
+
+// RestoreResult is the outcome of reopening one app.
+type RestoreResult struct {
+	App      string
+	BundleID string
+	Outcome  RestoreOutcome
+	Err      error
+}
+
+// RestoreApps reopens apps that are not running, continuing past failures.
+// With dryRun nothing is launched and missing apps are reported as
+// RestoreWouldLaunch. The returned error joins the launch errors.
+func RestoreApps(executor Executor, apps []AppInfo, dryRun bool) ([]RestoreResult, error) {
+	platform, err := platformForOS()
+	if err != nil {
+		return nil, err
+	}
+
+	running, err := platform.RunningApps(executor)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RestoreResult, 0, len(apps))
+	var errs []error
+	for _, app := range apps {
+		result := RestoreResult{App: app.Name, BundleID: app.BundleID}
+		switch {
+		case isAppRunning(running, app):
+			result.Outcome = RestoreAlreadyRunning
+		case dryRun:
+			result.Outcome = RestoreWouldLaunch
+		default:
+			result.Outcome = RestoreLaunched
+			if err := platform.Launch(executor, app); err != nil {
+				result.Outcome, result.Err = RestoreFailed, err
+				errs = append(errs, err)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, errors.Join(errs...)
+}
+
+// isAppRunning matches app against the running apps by bundle ID when both
+// sides know it, and by case-insensitive name otherwise.
+func isAppRunning(running []AppInfo, app AppInfo) bool {
+	for _, candidate := range running {
+		if app.BundleID != "" && candidate.BundleID != "" {
+			if strings.EqualFold(app.BundleID, candidate.BundleID) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(app.Name), strings.TrimSpace(candidate.Name)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package zencli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRestoreApps(t *testing.T) {
+	launchErr := errors.New("launch failed")
+	fake := &fakePlatform{
+		running:    []string{"Terminal", "slack"},
+		launchErrs: map[string]error{"Music": launchErr},
+	}
+	useFakePlatform(t, fake)
+
+	results, err := RestoreApps(&mockExecutor{}, appInfos("Safari", "Slack", "Music"), false)
+	if !errors.Is(err, launchErr) {
+		t.Fatalf("unexpected error: got %v want %v", err, launchErr)
+	}
+
+	want := []RestoreResult{
+		{App: "Safari", Outcome: RestoreLaunched},
+		{App: "Slack", Outcome: RestoreAlreadyRunning},
+		{App: "Music", Outcome: RestoreFailed, Err: launchErr},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: got %+v want %+v", results, want)
+	}
+	if !reflect.DeepEqual(fake.launched, []string{"Safari", "Music"}) {
+		t.Fatalf("unexpected launch calls: %v", fake.launched)
+	}
+}
+
+func TestRestoreAppsDryRun(t *testing.T) {
+	fake := &fakePlatform{running: []string{"Slack"}}
+	useFakePlatform(t, fake)
+
+	results, err := RestoreApps(&mockExecutor{}, appInfos("Safari", "Slack"), true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := []RestoreResult{
+		{App: "Safari", Outcome: RestoreWouldLaunch},
+		{App: "Slack", Outcome: RestoreAlreadyRunning},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: got %+v want %+v", results, want)
+	}
+	if len(fake.launched) != 0 {
+		t.Fatalf("expected no launches, got %v", fake.launched)
+	}
+}
+
+func TestIsAppRunningPrefersBundleID(t *testing.T) {
+	running := []AppInfo{{Name: "Code", BundleID: "com.microsoft.VSCode"}}
+
+	if !isAppRunning(running, AppInfo{Name: "Visual Studio Code", BundleID: "com.microsoft.vscode"}) {
+		t.Fatal("expected renamed app to match by bundle ID")
+	}
+	if isAppRunning(running, AppInfo{Name: "Code", BundleID: "com.example.code"}) {
+		t.Fatal("expected different bundle ID not to match")
+	}
+	if !isAppRunning(running, AppInfo{Name: "code"}) {
+		t.Fatal("expected name match without bundle ID")
+	}
+}
@@ -0,0 +1,405 @@
+package zencli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCronDuration bounds how long a cron rule stays active after it fires.
+const MaxCronDuration = 7 * 24 * time.Hour
+
+// cronSearchDays bounds the search for the next firing of a cron rule. Five
+// years covers rules that only fire on February 29.
+const cronSearchDays = 5 * 366
+
+// ScheduleRuleSpec describes a rule as written in the config. A rule is
+// either a daily time range (Start and End as HH:MM, optionally limited to
+// Days) or a five-field cron expression active for Duration after each
+// firing. Times are wall-clock times in Location, which defaults to UTC.
+type ScheduleRuleSpec struct {
+	Name     string
+	Profile  string
+	Days     []string
+	Start    string
+	End      string
+	Cron     string
+	Duration time.Duration
+	Location *time.Location
+}
+
+// ScheduleRule is a compiled schedule rule. Its methods are pure: they only
+// depend on the rule and the time passed in.
+type ScheduleRule struct {
+	Name     string
+	Profile  string
+	location *time.Location
+	days     [7]bool
+	start    int
+	end      int
+	cron     *cronSpec
+	duration time.Duration
+}
+
+// ScheduleWindow is one period during which a rule is active.
+type ScheduleWindow struct {
+	Rule    string
+	Profile string
+	Start   time.Time
+	End     time.Time
+}
+
+// CompileScheduleRule validates spec and compiles it.
+func CompileScheduleRule(spec ScheduleRuleSpec) (ScheduleRule, error) {
+	rule := ScheduleRule{
+		Name:     strings.TrimSpace(spec.Name),
+		Profile:  strings.TrimSpace(spec.Profile),
+		location: spec.Location,
+	}
+	if rule.location == nil {
+		rule.location = time.UTC
+	}
+	if rule.Name == "" {
+		return ScheduleRule{}, errors.New("schedule rule needs a name")
+	}
+
+	if strings.TrimSpace(spec.Cron) != "" {
+		if spec.Start != "" || spec.End != "" || len(spec.Days) != 0 {
+			return ScheduleRule{}, errors.New("cron rules cannot also set days, start or end")
+		}
+		if spec.Duration <= 0 || spec.Duration > MaxCronDuration {
+			return ScheduleRule{}, fmt.Errorf("cron rules need a positive duration of at most %s", MaxCronDuration)
+		}
+		cron, err := parseCron(spec.Cron)
+		if err != nil {
+			return ScheduleRule{}, err
+		}
+		rule.cron, rule.duration = cron, spec.Duration
+		return rule, nil
+	}
+
+	if spec.Duration != 0 {
+		return ScheduleRule{}, errors.New("duration is only used by cron rules; use start and end")
+	}
+	var err error
+	if rule.start, err = parseClock(spec.Start); err != nil {
+		return ScheduleRule{}, fmt.Errorf("invalid start: %w", err)
+	}
+	if rule.end, err = parseClock(spec.End); err != nil {
+		return ScheduleRule{}, fmt.Errorf("invalid end: %w", err)
+	}
+	if rule.start == rule.end {
+		return ScheduleRule{}, errors.New("start and end must differ")
+	}
+	if rule.days, err = parseDays(spec.Days); err != nil {
+		return ScheduleRule{}, err
+	}
+	return rule, nil
+}
+
+// Location returns the time zone the rule is evaluated in.
+func (r ScheduleRule) Location() *time.Location {
+	return r.location
+}
+
+// ActiveAt returns the window of r containing t. When windows overlap, the
+// one ending last is returned.
+func (r ScheduleRule) ActiveAt(t time.Time) (ScheduleWindow, bool) {
+	t = t.In(r.location)
+	// A range window lasts less than a day; a cron window at most duration.
+	lookback := 24 * time.Hour
+	if r.cron != nil {
+		lookback = r.duration
+	}
+
+	var active ScheduleWindow
+	found := false
+	first := dayStart(t.Add(-lookback))
+	for day := first; !day.After(t); day = nextDay(day) {
+		for _, window := range r.windowsOn(day) {
+			if window.Start.After(t) || !window.End.After(t) {
+				continue
+			}
+			if !found || window.End.After(active.End) {
+				active, found = window, true
+			}
+		}
+	}
+	return active, found
+}
+
+// NextStart returns the first window of r starting after t.
+func (r ScheduleRule) NextStart(t time.Time) (ScheduleWindow, bool) {
+	t = t.In(r.location)
+	limit := 8
+	if r.cron != nil {
+		limit = cronSearchDays
+	}
+
+	day := dayStart(t)
+	for i := 0; i < limit; i++ {
+		for _, window := range r.windowsOn(day) {
+			if window.Start.After(t) {
+				return window, true
+			}
+		}
+		day = nextDay(day)
+	}
+	return ScheduleWindow{}, false
+}
+
+// windowsOn returns the windows of r starting on day, in start order.
+func (r ScheduleRule) windowsOn(day time.Time) []ScheduleWindow {
+	if r.cron == nil {
+		if !r.days[day.Weekday()] {
+			return nil
+		}
+		end := atClock(day, r.end)
+		if r.end < r.start {
+			end = atClock(nextDay(day), r.end)
+		}
+		return []ScheduleWindow{r.window(atClock(day, r.start), end)}
+	}
+
+	if !r.cron.matchesDay(day) {
+		return nil
+	}
+	var windows []ScheduleWindow
+	for _, hour := range r.cron.hours {
+		for _, minute := range r.cron.minutes {
+			start := atClock(day, hour*60+minute)
+			windows = append(windows, r.window(start, start.Add(r.duration)))
+		}
+	}
+	return windows
+}
+
+func (r ScheduleRule) window(start, end time.Time) ScheduleWindow {
+	return ScheduleWindow{Rule: r.Name, Profile: r.Profile, Start: start, End: end}
+}
+
+// ActiveWindow returns the window active at t. Rules are in precedence
+// order: when several are active, the first one wins.
+func ActiveWindow(rules []ScheduleRule, t time.Time) (ScheduleWindow, bool) {
+	for _, rule := range rules {
+		if window, ok := rule.ActiveAt(t); ok {
+			return window, true
+		}
+	}
+	return ScheduleWindow{}, false
+}
+
+// NextWindow returns the earliest window of any rule starting after t. On a
+// tie the earlier rule wins.
+func NextWindow(rules []ScheduleRule, t time.Time) (ScheduleWindow, bool) {
+	var next ScheduleWindow
+	found := false
+	for _, rule := range rules {
+		window, ok := rule.NextStart(t)
+		if ok && (!found || window.Start.Before(next.Start)) {
+			next, found = window, true
+		}
+	}
+	return next, found
+}
+
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func nextDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location())
+}
+
+// atClock returns the wall-clock time minutes after midnight on day. Times
+// skipped by a daylight saving change move forward like time.Date does.
+func atClock(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+func parseClock(raw string) (int, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", raw)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// parseDays reads day names (mon, tuesday), ranges (mon-fri, which may wrap
+// like fri-mon) and the shorthands weekdays, weekends and daily. No days
+// means every day.
+func parseDays(days []string) ([7]bool, error) {
+	var set [7]bool
+	if len(days) == 0 {
+		return [7]bool{true, true, true, true, true, true, true}, nil
+	}
+	for _, raw := range days {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		switch name {
+		case "daily", "*":
+			return [7]bool{true, true, true, true, true, true, true}, nil
+		case "weekdays":
+			for d := time.Monday; d <= time.Friday; d++ {
+				set[d] = true
+			}
+			continue
+		case "weekends":
+			set[time.Saturday], set[time.Sunday] = true, true
+			continue
+		}
+
+		from, to, isRange := strings.Cut(name, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return set, fmt.Errorf("unknown day %q", raw)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return set, fmt.Errorf("unknown day %q", raw)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			set[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return set, nil
+}
+
+// cronSpec is a parsed five-field cron expression: minute, hour, day of
+// month, month and day of week.
+type cronSpec struct {
+	minutes []int
+	hours   []int
+	dom     [32]bool
+	months  [13]bool
+	dow     [7]bool
+	// Like cron, a day matches either field when both day of month and day
+	// of week are restricted. A field starting with "*", such as "*/1", is
+	// unrestricted even when its step skips days.
+	domAny bool
+	dowAny bool
+}
+
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron %q: expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+
+	var spec cronSpec
+	var err error
+	parse := func(i int, min, max int) []int {
+		if err != nil {
+			return nil
+		}
+		var values []int
+		values, err = parseCronField(fields[i], min, max)
+		if err != nil {
+			err = fmt.Errorf("invalid cron %q: %w", expr, err)
+		}
+		return values
+	}
+
+	minutes := parse(0, 0, 59)
+	hours := parse(1, 0, 23)
+	dom := parse(2, 1, 31)
+	months := parse(3, 1, 12)
+	dow := parse(4, 0, 7)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.minutes, spec.hours = minutes, hours
+	for _, d := range dom {
+		spec.dom[d] = true
+	}
+	for _, m := range months {
+		spec.months[m] = true
+	}
+	for _, d := range dow {
+		spec.dow[d%7] = true
+	}
+	spec.domAny = strings.HasPrefix(fields[2], "*")
+	spec.dowAny = strings.HasPrefix(fields[4], "*")
+	return &spec, nil
+}
+
+// parseCronField reads *, numbers, ranges (1-5), steps (*/15, 1-30/5) and
+// comma-separated lists of them.
+func parseCronField(field string, min, max int) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		base, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if base != "*" {
+			from, to, isRange := strings.Cut(base, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			seen[v] = true
+		}
+	}
+
+	values := make([]int, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+func (c *cronSpec) matchesDay(day time.Time) bool {
+	if !c.months[day.Month()] {
+		return false
+	}
+	dom, dow := c.dom[day.Day()], c.dow[day.Weekday()]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
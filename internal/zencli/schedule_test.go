@@ -0,0 +1,217 @@
+package zencli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustCompileRule(t *testing.T, spec ScheduleRuleSpec) ScheduleRule {
+	t.Helper()
+	rule, err := CompileScheduleRule(spec)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return rule
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s is not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestScheduleRuleActiveAtRange(t *testing.T) {
+	rule := mustCompileRule(t, ScheduleRuleSpec{Name: "deep-work", Profile: "coding", Days: []string{"weekdays"}, Start: "09:00", End: "12:00"})
+
+	// 2026-03-02 is a Monday.
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	cases := map[time.Duration]bool{
+		8*time.Hour + 59*time.Minute:  false,
+		9 * time.Hour:                 true,
+		11*time.Hour + 59*time.Minute: true,
+		12 * time.Hour:                false,
+		5*24*time.Hour + 10*time.Hour: false, // Saturday
+	}
+	for offset, want := range cases {
+		window, got := rule.ActiveAt(monday.Add(offset))
+		if got != want {
+			t.Fatalf("unexpected activity at %s: got %v want %v", monday.Add(offset), got, want)
+		}
+		if got {
+			wantWindow := ScheduleWindow{Rule: "deep-work", Profile: "coding", Start: monday.Add(9 * time.Hour), End: monday.Add(12 * time.Hour)}
+			if !reflect.DeepEqual(window, wantWindow) {
+				t.Fatalf("unexpected window: got %+v want %+v", window, wantWindow)
+			}
+		}
+	}
+}
+
+func TestScheduleRuleOvernightRange(t *testing.T) {
+	rule := mustCompileRule(t, ScheduleRuleSpec{Name: "late", Days: []string{"fri"}, Start: "22:00", End: "02:00"})
+
+	// 2026-03-07 01:00 is Saturday, inside the window opened on Friday.
+	window, ok := rule.ActiveAt(time.Date(2026, 3, 7, 1, 0, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("expected the Friday window to be active after midnight")
+	}
+	if want := time.Date(2026, 3, 6, 22, 0, 0, 0, time.UTC); !window.Start.Equal(want) {
+		t.Fatalf("unexpected start: got %s want %s", window.Start, want)
+	}
+	if _, ok := rule.ActiveAt(time.Date(2026, 3, 8, 1, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("expected no window after Saturday night")
+	}
+}
+
+func TestScheduleRuleUsesItsTimeZone(t *testing.T) {
+	tokyo := mustLocation(t, "Asia/Tokyo")
+	rule := mustCompileRule(t, ScheduleRuleSpec{Name: "morning", Start: "09:00", End: "10:00", Location: tokyo})
+
+	// 00:30 UTC is 09:30 in Tokyo.
+	window, ok := rule.ActiveAt(time.Date(2026, 3, 2, 0, 30, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("expected the rule to be active at 09:30 Tokyo time")
+	}
+	if want := time.Date(2026, 3, 2, 9, 0, 0, 0, tokyo); !window.Start.Equal(want) {
+		t.Fatalf("unexpected start: got %s want %s", window.Start, want)
+	}
+}
+
+func TestScheduleRuleAcrossDaylightSaving(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	rule := mustCompileRule(t, ScheduleRuleSpec{Name: "morning", Start: "09:00", End: "10:00", Location: berlin})
+
+	// Clocks moved forward on 2026-03-29; the window stays at 09:00 local.
+	next, ok := rule.NextStart(time.Date(2026, 3, 28, 12, 0, 0, 0, berlin))
+	if !ok {
+		t.Fatal("expected a next window")
+	}
+	if want := time.Date(2026, 3, 29, 7, 0, 0, 0, time.UTC); !next.Start.Equal(want) {
+		t.Fatalf("unexpected start: got %s want %s", next.Start.UTC(), want)
+	}
+}
+
+func TestScheduleRuleCron(t *testing.T) {
+	rule := mustCompileRule(t, ScheduleRuleSpec{Name: "writing", Profile: "writing", Cron: "0 14 * * 1-5", Duration: 2 * time.Hour})
+
+	window, ok := rule.ActiveAt(time.Date(2026, 3, 2, 15, 30, 0, 0, time.UTC))
+	if !ok || !window.End.Equal(time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected window: %+v %v", window, ok)
+	}
+	if _, ok := rule.ActiveAt(time.Date(2026, 3, 7, 15, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("expected no window on Saturday")
+	}
+
+	// Friday afternoon: the next firing is on Monday.
+	next, ok := rule.NextStart(time.Date(2026, 3, 6, 14, 0, 0, 0, time.UTC))
+	if !ok || !next.Start.Equal(time.Date(2026, 3, 9, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected next window: %+v %v", next, ok)
+	}
+}
+
+func TestScheduleRuleCronLeapDay(t *testing.T) {
+	rule := mustCompileRule(t, ScheduleRuleSpec{Name: "leap", Cron: "30 8 29 2 *", Duration: time.Hour})
+	next, ok := rule.NextStart(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if !ok || !next.Start.Equal(time.Date(2028, 2, 29, 8, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected next window: %+v %v", next, ok)
+	}
+}
+
+func TestParseCronDayMatching(t *testing.T) {
+	spec, err := parseCron("*/20 9-10 1,15 * 0")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(spec.minutes, []int{0, 20, 40}) || !reflect.DeepEqual(spec.hours, []int{9, 10}) {
+		t.Fatalf("unexpected spec: %+v", spec)
+	}
+	// Both day fields are restricted, so either one matches.
+	for day, want := range map[int]bool{1: true, 8: true, 9: false, 15: true} {
+		if got := spec.matchesDay(time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC)); got != want {
+			t.Fatalf("unexpected match on March %d: got %v want %v", day, got, want)
+		}
+	}
+}
+
+func TestParseCronStarStepIsUnrestricted(t *testing.T) {
+	spec, err := parseCron("0 9 */1 * 1-5")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	// March 2, 2026 is a Monday.
+	for day, want := range map[int]bool{2: true, 6: true, 7: false, 8: false, 9: true} {
+		if got := spec.matchesDay(time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC)); got != want {
+			t.Fatalf("unexpected match on March %d: got %v want %v", day, got, want)
+		}
+	}
+}
+
+func TestCompileScheduleRuleErrors(t *testing.T) {
+	cases := []struct {
+		spec ScheduleRuleSpec
+		want string
+	}{
+		{ScheduleRuleSpec{Start: "09:00", End: "10:00"}, "needs a name"},
+		{ScheduleRuleSpec{Name: "a", Start: "9am", End: "10:00"}, "invalid start"},
+		{ScheduleRuleSpec{Name: "a", Start: "09:00", End: "09:00"}, "must differ"},
+		{ScheduleRuleSpec{Name: "a", Start: "09:00", End: "10:00", Days: []string{"someday"}}, "unknown day"},
+		{ScheduleRuleSpec{Name: "a", Start: "09:00", End: "10:00", Duration: time.Hour}, "only used by cron"},
+		{ScheduleRuleSpec{Name: "a", Cron: "0 9 * *", Duration: time.Hour}, "5 fields"},
+		{ScheduleRuleSpec{Name: "a", Cron: "0 24 * * *", Duration: time.Hour}, "outside 0-23"},
+		{ScheduleRuleSpec{Name: "a", Cron: "0 9 * * *"}, "positive duration"},
+		{ScheduleRuleSpec{Name: "a", Cron: "0 9 * * *", Duration: time.Hour, Start: "09:00"}, "cannot also set"},
+	}
+	for _, tc := range cases {
+		_, err := CompileScheduleRule(tc.spec)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("expected %q error for %+v, got %v", tc.want, tc.spec, err)
+		}
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	days, err := parseDays([]string{"fri-mon", "Wednesday"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := [7]bool{true, true, false, true, false, true, true}
+	if days != want {
+		t.Fatalf("unexpected days: got %v want %v", days, want)
+	}
+}
+
+func TestActiveAndNextWindow(t *testing.T) {
+	rules := []ScheduleRule{
+		mustCompileRule(t, ScheduleRuleSpec{Name: "meeting", Profile: "meeting", Days: []string{"mon"}, Start: "10:00", End: "11:00"}),
+		mustCompileRule(t, ScheduleRuleSpec{Name: "deep-work", Profile: "coding", Days: []string{"weekdays"}, Start: "09:00", End: "12:00"}),
+		mustCompileRule(t, ScheduleRuleSpec{Name: "writing", Profile: "writing", Days: []string{"weekdays"}, Start: "14:00", End: "16:00"}),
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+	}
+
+	window, ok := ActiveWindow(rules, at(10, 30))
+	if !ok || window.Rule != "meeting" {
+		t.Fatalf("expected the first active rule to win, got %+v %v", window, ok)
+	}
+	window, ok = ActiveWindow(rules, at(11, 30))
+	if !ok || window.Rule != "deep-work" {
+		t.Fatalf("unexpected active window: %+v %v", window, ok)
+	}
+	if _, ok := ActiveWindow(rules, at(13, 0)); ok {
+		t.Fatal("expected no active window at 13:00")
+	}
+
+	next, ok := NextWindow(rules, at(9, 30))
+	if !ok || next.Rule != "meeting" || !next.Start.Equal(at(10, 0)) {
+		t.Fatalf("unexpected next window: %+v %v", next, ok)
+	}
+	next, ok = NextWindow(rules, at(12, 0))
+	if !ok || next.Rule != "writing" || !next.Start.Equal(at(14, 0)) {
+		t.Fatalf("unexpected next window: %+v %v", next, ok)
+	}
+}
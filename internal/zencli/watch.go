@@ -0,0 +1,162 @@
+package zencli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MinWatchInterval bounds how often Watch lists running apps so the
+	// loop does not hammer osascript or wmctrl.
+	MinWatchInterval = time.Second
+	// DefaultQuitCooldown is how long Watch waits before retrying an app it
+	// already tried to quit, giving slow apps time to exit.
+	DefaultQuitCooldown = 30 * time.Second
+	// DefaultMaxScanFailures is how many scans in a row may fail to list the
+	// running apps before Watch gives up. Listing often fails for a moment
+	// while the machine wakes from sleep.
+	DefaultMaxScanFailures = 10
+)
+
+// Clock abstracts time so polling loops can be tested without sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SystemClock is the wall clock.
+var SystemClock Clock = systemClock{}
+
+// WatchEvent reports one quit attempt made by Watch. Result holds the
+// outcome of every step; App and Err repeat its name and error. Events
+// passed to OnScanError only set Time and Err.
+type WatchEvent struct {
+	Time   time.Time
+	App    string
+	Err    error
+	Result QuitResult
+}
+
+type WatchOptions struct {
+	// Interval between two scans; values below MinWatchInterval are raised.
+	Interval time.Duration
+	// Cooldown is the minimum time between two quit attempts for the same
+	// app. Zero uses DefaultQuitCooldown.
+	Cooldown time.Duration
+	// Deadline stops the loop once reached. The zero time means no deadline.
+	Deadline time.Time
+	// Clock defaults to SystemClock.
+	Clock Clock
+	// OnQuit is called after every quit attempt.
+	OnQuit func(WatchEvent)
+	// OnScanError is called when listing the running apps fails and Watch
+	// keeps going.
+	OnScanError func(WatchEvent)
+	// MaxScanFailures is how many scans in a row may fail before Watch
+	// returns the error. Zero uses DefaultMaxScanFailures.
+	MaxScanFailures int
+	// Options, when set, is called before every scan and replaces the
+	// options passed to Watch, so the allow-list can change while watching.
+	// The scan is skipped when it returns false.
+	Options func(now time.Time) (Options, bool)
+}
+
+// Watch repeatedly quits target apps until ctx is cancelled or the deadline
+// passes. Cancellation is a normal stop and returns nil. A failed listing is
+// retried at the next scan; only MaxScanFailures failures in a row end the
+// loop with an error.
+func Watch(ctx context.Context, executor Executor, opts Options, watch WatchOptions) error {
+	platform, err := platformForOS()
+	if err != nil {
+		return err
+	}
+
+	clock := watch.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	interval := watch.Interval
+	if interval < MinWatchInterval {
+		interval = MinWatchInterval
+	}
+	cooldown := watch.Cooldown
+	if cooldown <= 0 {
+		cooldown = DefaultQuitCooldown
+	}
+	maxFailures := watch.MaxScanFailures
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxScanFailures
+	}
+
+	lastAttempt := make(map[string]time.Time)
+	failures := 0
+	for {
+		now := clock.Now()
+		if !watch.Deadline.IsZero() && !now.Before(watch.Deadline) {
+			return nil
+		}
+
+		scan := true
+		if watch.Options != nil {
+			opts, scan = watch.Options(now)
+		}
+		var targets []AppInfo
+		if scan {
+			targets, err = previewWithPlatform(executor, platform, opts)
+			switch {
+			case err == nil:
+				failures = 0
+			case failures+1 >= maxFailures:
+				return fmt.Errorf("failed to list running apps %d times in a row: %w", failures+1, err)
+			default:
+				failures++
+				scan = false
+				if watch.OnScanError != nil {
+					watch.OnScanError(WatchEvent{Time: now, Err: err})
+				}
+			}
+		}
+		if scan {
+			due := make([]AppInfo, 0, len(targets))
+			for _, app := range targets {
+				key := strings.ToLower(app.Name)
+				if last, ok := lastAttempt[key]; ok && now.Sub(last) < cooldown {
+					continue
+				}
+				lastAttempt[key] = now
+				due = append(due, app)
+			}
+
+			for _, result := range quitApps(executor, platform, due, opts) {
+				if watch.OnQuit != nil {
+					watch.OnQuit(WatchEvent{Time: now, App: result.App, Err: result.Err, Result: result})
+				}
+			}
+		}
+
+		wait := interval
+		if !watch.Deadline.IsZero() {
+			if left := watch.Deadline.Sub(now); left < wait {
+				wait = left
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-clock.After(wait):
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package zencli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock advances instantly whenever After is called.
+type fakeClock struct {
+	now    time.Time
+	waits  []time.Duration
+	frozen bool
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	if c.frozen {
+		return nil
+	}
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestWatchQuitsNewTargetsUntilDeadline(t *testing.T) {
+	fake := &fakePlatform{scans: [][]string{
+		{"Terminal", "Slack"},
+		{"Terminal", "Slack"},
+		{"Terminal", "Slack", "Music"},
+		{"Terminal"},
+	}}
+	useFakePlatform(t, fake)
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	var events []string
+	err := Watch(context.Background(), &mockExecutor{}, Options{}, WatchOptions{
+		Interval: 10 * time.Second,
+		Cooldown: 15 * time.Second,
+		Deadline: start.Add(35 * time.Second),
+		Clock:    clock,
+		OnQuit: func(event WatchEvent) {
+			events = append(events, event.Time.Sub(start).String()+" "+event.App)
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	wantEvents := []string{"0s Slack", "20s Music", "20s Slack"}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Fatalf("unexpected events: got %v want %v", events, wantEvents)
+	}
+	wantWaits := []time.Duration{10 * time.Second, 10 * time.Second, 10 * time.Second, 5 * time.Second}
+	if !reflect.DeepEqual(clock.waits, wantWaits) {
+		t.Fatalf("unexpected waits: got %v want %v", clock.waits, wantWaits)
+	}
+}
+
+func TestWatchStopsOnCancel(t *testing.T) {
+	useFakePlatform(t, &fakePlatform{running: []string{"Slack"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	clock := &fakeClock{now: time.Now(), frozen: true}
+
+	err := Watch(ctx, &mockExecutor{}, Options{}, WatchOptions{Clock: clock})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(clock.waits, []time.Duration{MinWatchInterval}) {
+		t.Fatalf("expected the interval to be raised to the minimum, got %v", clock.waits)
+	}
+}
+
+func TestWatchReportsQuitErrors(t *testing.T) {
+	fake := &fakePlatform{
+		running:  []string{"Slack"},
+		quitErrs: map[string]error{"Slack": errors.New("quit refused")},
+	}
+	useFakePlatform(t, fake)
+
+	start := time.Now()
+	var got []error
+	err := Watch(context.Background(), &mockExecutor{}, Options{}, WatchOptions{
+		Interval: time.Minute,
+		Deadline: start.Add(time.Minute),
+		Clock:    &fakeClock{now: start},
+		OnQuit: func(event WatchEvent) {
+			got = append(got, event.Err)
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(got) != 1 || got[0] == nil {
+		t.Fatalf("expected one failed quit, got %v", got)
+	}
+}
+
+func TestWatchRetriesFailedScans(t *testing.T) {
+	listErr := errors.New("wmctrl: cannot open display")
+	useFakePlatform(t, &fakePlatform{running: []string{"Slack"}, listErrs: []error{listErr, nil}})
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	var scanErrs []error
+	var events []string
+	err := Watch(context.Background(), &mockExecutor{}, Options{}, WatchOptions{
+		Interval: 10 * time.Second,
+		Deadline: start.Add(15 * time.Second),
+		Clock:    &fakeClock{now: start},
+		OnQuit: func(event WatchEvent) {
+			events = append(events, event.Time.Sub(start).String()+" "+event.App)
+		},
+		OnScanError: func(event WatchEvent) {
+			scanErrs = append(scanErrs, event.Err)
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(scanErrs, []error{listErr}) {
+		t.Fatalf("unexpected scan errors: got %v want %v", scanErrs, []error{listErr})
+	}
+	if !reflect.DeepEqual(events, []string{"10s Slack"}) {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
+
+func TestWatchGivesUpAfterRepeatedScanFailures(t *testing.T) {
+	listErr := errors.New("osascript: connection invalid")
+	useFakePlatform(t, &fakePlatform{running: []string{"Slack"}, listErrs: []error{listErr, listErr, listErr}})
+
+	reported := 0
+	err := Watch(context.Background(), &mockExecutor{}, Options{}, WatchOptions{
+		Clock:           &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)},
+		MaxScanFailures: 3,
+		OnScanError: func(WatchEvent) {
+			reported++
+		},
+	})
+	if !errors.Is(err, listErr) {
+		t.Fatalf("unexpected error: got %v want %v", err, listErr)
+	}
+	if reported != 2 {
+		t.Fatalf("unexpected reported failures: got %d want 2", reported)
+	}
+}
+
+func TestWatchUsesReloadedOptions(t *testing.T) {
+	useFakePlatform(t, &fakePlatform{running: []string{"Terminal", "Slack", "Music"}})
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	scans := 0
+	var events []string
+	err := Watch(context.Background(), &mockExecutor{}, Options{}, WatchOptions{
+		Interval: 10 * time.Second,
+		Cooldown: time.Second,
+		Deadline: start.Add(15 * time.Second),
+		Clock:    &fakeClock{now: start},
+		Options: func(time.Time) (Options, bool) {
+			scans++
+			if scans == 1 {
+				return Options{AllowedApps: []string{"Slack"}}, true
+			}
+			return Options{AllowedApps: []string{"Music"}}, true
+		},
+		OnQuit: func(event WatchEvent) {
+			events = append(events, event.Time.Sub(start).String()+" "+event.App)
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	wantEvents := []string{"0s Music", "10s Slack"}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Fatalf("unexpected events: got %v want %v", events, wantEvents)
+	}
+}
+
+func TestWatchSkipsInactiveScans(t *testing.T) {
+	useFakePlatform(t, &fakePlatform{running: []string{"Slack"}})
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	var events []string
+	err := Watch(context.Background(), &mockExecutor{}, Options{}, WatchOptions{
+		Interval: 10 * time.Second,
+		Cooldown: time.Second,
+		Deadline: start.Add(25 * time.Second),
+		Clock:    &fakeClock{now: start},
+		Options: func(now time.Time) (Options, bool) {
+			return Options{}, now.Sub(start) >= 20*time.Second
+		},
+		OnQuit: func(event WatchEvent) {
+			events = append(events, event.Time.Sub(start).String()+" "+event.App)
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if want := []string{"20s Slack"}; !reflect.DeepEqual(events, want) {
+		t.Fatalf("unexpected events: got %v want %v", events, want)
+	}
+}
@@ -1,15 +1,16 @@
 package zencli
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"os/exec"
-	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
-var ErrUnsupportedOS = errors.New("zen-cli supports macOS only")
+var ErrUnsupportedOS = errors.New("zen-cli supports macOS and Linux only")
 
 // defaultAllowedApps is the predefined allow-list required by the product.
 var defaultAllowedApps = []string{
@@ -22,6 +23,8 @@ var defaultAllowedApps = []string{
 	"Activity Monitor",
 }
 
+// Executor runs external commands. Implementations must be safe for
+// concurrent use when Options.Concurrency is above one.
 type Executor interface {
 	Run(name string, args ...string) ([]byte, error)
 }
@@ -32,13 +35,61 @@ func (OSExecutor) Run(name string, args ...string) ([]byte, error) {
 	return exec.Command(name, args...).CombinedOutput()
 }
 
+// contextWaitDelay is how long RunContext waits for the output pipes to
+// close after the command was killed.
+const contextWaitDelay = time.Second
+
+func (OSExecutor) RunContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	startProcessGroup(cmd)
+	cmd.WaitDelay = contextWaitDelay
+	return cmd.CombinedOutput()
+}
+
+// StepOutcome describes how one step of closing an app went.
+type StepOutcome string
+
+const (
+	StepSkipped    StepOutcome = "skipped"
+	StepOK         StepOutcome = "ok"
+	StepFailed     StepOutcome = "failed"
+	StepNotRunning StepOutcome = "not-running"
+)
+
+// QuitResult is the outcome of closing one app. Err is nil when the app is
+// no longer running.
+type QuitResult struct {
+	App       string
+	BundleID  string
+	Graceful  StepOutcome
+	Terminate StepOutcome
+	ForceKill StepOutcome
+	Err       error
+}
+
+// ClosedApps returns the apps of results that were closed successfully.
+func ClosedApps(results []QuitResult) []string {
+	closed := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.Err == nil {
+			closed = append(closed, result.App)
+		}
+	}
+	return closed
+}
+
 type Options struct {
 	AllowedApps           []string
 	DisallowedApps        []string
 	ReplaceDefaultAllowed bool
+	// Concurrency is the number of apps quit at the same time. Values
+	// below one quit apps one by one.
+	Concurrency int
+	// Escalation decides what happens to apps that ignore the graceful quit.
+	Escalation EscalationPolicy
 }
 
-func Execute(executor Executor) ([]string, error) {
+func Execute(executor Executor) ([]QuitResult, error) {
 	return ExecuteWithOptions(executor, Options{})
 }
 
@@ -46,34 +97,127 @@ func EffectiveAllowedApps(opts Options) []string {
 	return resolveAllowedApps(opts)
 }
 
-func PreviewWithOptions(executor Executor, opts Options) ([]string, error) {
-	if runtime.GOOS != "darwin" {
-		return nil, ErrUnsupportedOS
+// MatchStatus tells why a running app is or is not a target.
+type MatchStatus string
+
+const (
+	MatchAllowed   MatchStatus = "allowed"
+	MatchProtected MatchStatus = "protected"
+	MatchTarget    MatchStatus = "target"
+)
+
+// AppMatch describes how a running app was classified. Pattern is the
+// allowed entry that kept the app, or the disallowed entry that made it a
+// target; it is empty when no entry matched.
+type AppMatch struct {
+	App      string
+	BundleID string
+	Status   MatchStatus
+	Pattern  string
+}
+
+// MatchRunningApps lists the running apps and how each one is classified.
+func MatchRunningApps(executor Executor, opts Options) ([]AppMatch, error) {
+	platform, err := platformForOS()
+	if err != nil {
+		return nil, err
+	}
+
+	running, err := platform.RunningApps(executor)
+	if err != nil {
+		return nil, err
+	}
+	return matchRunningApps(running, opts, platform.ProtectedApps()...), nil
+}
+
+func PreviewWithOptions(executor Executor, opts Options) ([]AppInfo, error) {
+	platform, err := platformForOS()
+	if err != nil {
+		return nil, err
+	}
+
+	return previewWithPlatform(executor, platform, opts)
+}
+
+// ExecuteWithOptions closes every target app, continuing past failures. The
+// returned error joins the errors of all apps that could not be closed.
+func ExecuteWithOptions(executor Executor, opts Options) ([]QuitResult, error) {
+	platform, err := platformForOS()
+	if err != nil {
+		return nil, err
 	}
 
-	running, err := runningAppNames(executor)
+	targets, err := previewWithPlatform(executor, platform, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return targetAppsFromRunning(running, opts), nil
+	results := quitApps(executor, platform, targets, opts)
+	return results, joinQuitErrors(results)
 }
 
-func ExecuteWithOptions(executor Executor, opts Options) ([]string, error) {
-	targets, err := PreviewWithOptions(executor, opts)
+// QuitApps closes targets, typically returned by PreviewWithOptions, and
+// reports the outcome like ExecuteWithOptions.
+func QuitApps(executor Executor, targets []AppInfo, opts Options) ([]QuitResult, error) {
+	platform, err := platformForOS()
 	if err != nil {
 		return nil, err
 	}
 
-	killed := make([]string, 0, len(targets))
-	for _, app := range targets {
-		if err := quitApp(executor, app); err != nil {
-			return killed, err
+	results := quitApps(executor, platform, targets, opts)
+	return results, joinQuitErrors(results)
+}
+
+func joinQuitErrors(results []QuitResult) error {
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
 		}
-		killed = append(killed, app)
 	}
+	return errors.Join(errs...)
+}
 
-	return killed, nil
+// ClosedAppInfos is like ClosedApps but keeps the bundle IDs, so the apps
+// can be launched again by bundle ID.
+func ClosedAppInfos(results []QuitResult) []AppInfo {
+	closed := make([]AppInfo, 0, len(results))
+	for _, result := range results {
+		if result.Err == nil {
+			closed = append(closed, AppInfo{Name: result.App, BundleID: result.BundleID})
+		}
+	}
+	return closed
+}
+
+// RelaunchApps starts every app again, by bundle ID when it is known,
+// continuing past failures. It returns the names of the apps that were
+// launched and the joined launch errors.
+func RelaunchApps(executor Executor, apps []AppInfo) ([]string, error) {
+	platform, err := platformForOS()
+	if err != nil {
+		return nil, err
+	}
+
+	launched := make([]string, 0, len(apps))
+	var errs []error
+	for _, app := range apps {
+		if err := platform.Launch(executor, app); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		launched = append(launched, app.Name)
+	}
+	return launched, errors.Join(errs...)
+}
+
+func previewWithPlatform(executor Executor, platform Platform, opts Options) ([]AppInfo, error) {
+	running, err := platform.RunningApps(executor)
+	if err != nil {
+		return nil, err
+	}
+
+	return targetAppsFromRunning(running, opts, platform.ProtectedApps()...), nil
 }
 
 func resolveAllowedApps(opts Options) []string {
@@ -104,18 +248,10 @@ func resolveAllowedApps(opts Options) []string {
 		return allowed
 	}
 
-	disallowed := make(map[string]struct{}, len(opts.DisallowedApps))
-	for _, app := range opts.DisallowedApps {
-		name := strings.TrimSpace(app)
-		if name == "" {
-			continue
-		}
-		disallowed[strings.ToLower(name)] = struct{}{}
-	}
-
+	disallowed := compilePatterns(opts.DisallowedApps)
 	filtered := make([]string, 0, len(allowed))
 	for _, app := range allowed {
-		if _, blocked := disallowed[strings.ToLower(strings.TrimSpace(app))]; blocked {
+		if isDisallowedEntry(app, disallowed) {
 			continue
 		}
 		filtered = append(filtered, app)
@@ -124,80 +260,125 @@ func resolveAllowedApps(opts Options) []string {
 	return filtered
 }
 
+// isDisallowedEntry reports whether an allow-list entry is cancelled by the
+// disallow list: either the same entry, or a plain name matched by a
+// disallowed pattern. Allowed patterns are narrowed at match time instead.
+func isDisallowedEntry(entry string, disallowed []AppPattern) bool {
+	key := strings.ToLower(strings.TrimSpace(entry))
+	for _, pattern := range disallowed {
+		if strings.ToLower(pattern.Raw) == key {
+			return true
+		}
+	}
+
+	parsed, err := ParsePattern(entry)
+	if err != nil || parsed.kind != patternLiteral {
+		return false
+	}
+	_, matched := firstMatch(disallowed, AppInfo{Name: entry})
+	return matched
+}
+
 func selfExecutableNames() []string {
 	return []string{"zen"}
 }
 
-func targetAppsFromRunning(running []string, opts Options) []string {
-	allowed := makeAllowedSet(resolveAllowedApps(opts))
-	for _, app := range selfExecutableNames() {
-		allowed[strings.ToLower(app)] = struct{}{}
+func targetAppsFromRunning(running []AppInfo, opts Options, protected ...string) []AppInfo {
+	classify := newAppClassifier(opts, protected...)
+	targets := make([]AppInfo, 0, len(running))
+	for _, app := range running {
+		if classify(app).Status == MatchTarget {
+			targets = append(targets, app)
+		}
 	}
-
-	targets := filterTargets(running, allowed)
-	sort.Strings(targets)
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Name < targets[j].Name
+	})
 	return targets
 }
 
-func runningAppNames(executor Executor) ([]string, error) {
-	script := `tell application "System Events" to get name of every application process whose background only is false`
-	out, err := executor.Run("osascript", "-e", script)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list running apps: %w: %s", err, strings.TrimSpace(string(out)))
+// matchRunningApps classifies every running app, sorted by name.
+func matchRunningApps(running []AppInfo, opts Options, protected ...string) []AppMatch {
+	classify := newAppClassifier(opts, protected...)
+	matches := make([]AppMatch, 0, len(running))
+	for _, app := range running {
+		matches = append(matches, classify(app))
 	}
-	return parseAppList(string(out)), nil
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].App < matches[j].App
+	})
+	return matches
 }
 
-func quitApp(executor Executor, appName string) error {
-	safeName := strings.ReplaceAll(appName, `"`, `\\\"`)
-	quitScript := fmt.Sprintf(`tell application "%s" to quit`, safeName)
-	if out, err := executor.Run("osascript", "-e", quitScript); err != nil {
-		return fmt.Errorf("failed to quit %s: %w: %s", appName, err, strings.TrimSpace(string(out)))
+// newAppClassifier returns the function deciding the fate of a running app.
+// The CLI itself and the platform's protected apps are never targets.
+func newAppClassifier(opts Options, protected ...string) func(AppInfo) AppMatch {
+	matcher := makeAllowedSet(resolveAllowedApps(opts))
+	matcher.disallowed = compilePatterns(opts.DisallowedApps)
+	protectedSet := make(map[string]struct{}, len(protected)+1)
+	for _, app := range append(selfExecutableNames(), protected...) {
+		protectedSet[strings.ToLower(app)] = struct{}{}
 	}
 
-	if out, err := executor.Run("pkill", "-x", appName); err != nil {
-		if strings.TrimSpace(string(out)) == "" {
-			// already closed
-			return nil
+	return func(app AppInfo) AppMatch {
+		if _, ok := protectedSet[strings.ToLower(strings.TrimSpace(app.Name))]; ok {
+			return AppMatch{App: app.Name, BundleID: app.BundleID, Status: MatchProtected}
 		}
-		return fmt.Errorf("failed to force close %s: %w: %s", appName, err, strings.TrimSpace(string(out)))
+		return matcher.match(app)
 	}
-	return nil
 }
 
-func parseAppList(raw string) []string {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return nil
+// quitApps quits targets through a pool of at most opts.Concurrency workers.
+// The results keep the order of targets regardless of completion order.
+func quitApps(executor Executor, platform Platform, targets []AppInfo, opts Options) []QuitResult {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(targets) {
+		concurrency = len(targets)
 	}
 
-	parts := strings.Split(raw, ",")
-	apps := make([]string, 0, len(parts))
-	for _, part := range parts {
-		name := strings.TrimSpace(part)
-		if name == "" {
-			continue
-		}
-		apps = append(apps, name)
+	results := make([]QuitResult, len(targets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = quitApp(executor, platform, targets[i], opts.Escalation)
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
 	}
-	return apps
+	close(jobs)
+	wg.Wait()
+
+	return results
 }
 
-func makeAllowedSet(apps []string) map[string]struct{} {
-	result := make(map[string]struct{}, len(apps))
-	for _, app := range apps {
-		result[strings.ToLower(app)] = struct{}{}
-	}
-	return result
+// appMatcher decides which running apps are allowed. An app is allowed when
+// it matches an allowed pattern and no disallowed pattern.
+type appMatcher struct {
+	allowed    []AppPattern
+	disallowed []AppPattern
 }
 
-func filterTargets(running []string, allowed map[string]struct{}) []string {
-	targets := make([]string, 0, len(running))
-	for _, app := range running {
-		if _, ok := allowed[strings.ToLower(app)]; ok {
-			continue
-		}
-		targets = append(targets, app)
+func makeAllowedSet(apps []string) appMatcher {
+	return appMatcher{allowed: compilePatterns(apps)}
+}
+
+func (m appMatcher) match(app AppInfo) AppMatch {
+	match := AppMatch{App: app.Name, BundleID: app.BundleID, Status: MatchTarget}
+	if blocked, ok := firstMatch(m.disallowed, app); ok {
+		match.Pattern = blocked.Raw
+		return match
 	}
-	return targets
+	if allowed, ok := firstMatch(m.allowed, app); ok {
+		match.Status, match.Pattern = MatchAllowed, allowed.Raw
+	}
+	return match
 }
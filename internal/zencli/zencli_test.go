@@ -3,7 +3,10 @@ package zencli
 import (
 	"errors"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type callResult struct {
@@ -12,6 +15,7 @@ type callResult struct {
 }
 
 type mockExecutor struct {
+	mu      sync.Mutex
 	calls   []string
 	results map[string]callResult
 }
@@ -21,6 +25,8 @@ func (m *mockExecutor) Run(name string, args ...string) ([]byte, error) {
 	for _, arg := range args {
 		call += "|" + arg
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.calls = append(m.calls, call)
 	if result, ok := m.results[call]; ok {
 		return result.output, result.err
@@ -28,22 +34,92 @@ func (m *mockExecutor) Run(name string, args ...string) ([]byte, error) {
 	return nil, nil
 }
 
-func TestParseAppList(t *testing.T) {
-	input := "Safari, Visual Studio Code, Slack"
-	got := parseAppList(input)
-	want := []string{"Safari", "Visual Studio Code", "Slack"}
+func TestParseAppInfoList(t *testing.T) {
+	input := record("Safari", "com.apple.Safari", "501") +
+		record("Visual Studio Code", "com.microsoft.VSCode", "733") +
+		record("Legacy Tool", "", "901") +
+		record("Broken", "com.example.broken", "missing value") +
+		"\n"
+	got := parseAppInfoList(input)
+	want := []AppInfo{
+		{Name: "Safari", BundleID: "com.apple.Safari", PID: 501},
+		{Name: "Visual Studio Code", BundleID: "com.microsoft.VSCode", PID: 733},
+		{Name: "Legacy Tool", PID: 901},
+		{Name: "Broken", BundleID: "com.example.broken"},
+	}
 	if !reflect.DeepEqual(got, want) {
-		t.Fatalf("unexpected parse result: got %v want %v", got, want)
+		t.Fatalf("unexpected parse result: got %+v want %+v", got, want)
+	}
+}
+
+func TestParseAppInfoListKeepsDelimitersInNames(t *testing.T) {
+	input := record("Foo, Inc. Helper", "com.foo.helper", "11") +
+		record(`Say "Hi"`, "com.example.sayhi", "12") +
+		record("メモ", "com.apple.Notes", "13") +
+		record("Café Ünïcode ✨", "", "14") +
+		record("Trailing Space ", "com.example.trailing", "15") +
+		record("Tab\tName", "com.example.tab", "16") +
+		"\n"
+	got := parseAppInfoList(input)
+	want := []AppInfo{
+		{Name: "Foo, Inc. Helper", BundleID: "com.foo.helper", PID: 11},
+		{Name: `Say "Hi"`, BundleID: "com.example.sayhi", PID: 12},
+		{Name: "メモ", BundleID: "com.apple.Notes", PID: 13},
+		{Name: "Café Ünïcode ✨", PID: 14},
+		{Name: "Trailing Space ", BundleID: "com.example.trailing", PID: 15},
+		{Name: "Tab\tName", BundleID: "com.example.tab", PID: 16},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected parse result: got %+v want %+v", got, want)
+	}
+}
+
+func TestParseAppInfoListEmpty(t *testing.T) {
+	for _, input := range []string{"", "\n", macRecordSeparator + "\n", record("  ", "", "1")} {
+		if got := parseAppInfoList(input); len(got) != 0 {
+			t.Fatalf("expected no apps for %q, got %+v", input, got)
+		}
 	}
 }
 
-func TestFilterTargets(t *testing.T) {
-	running := []string{"Terminal", "Safari", "Slack"}
+func TestTrailingSpaceNameMatchesEntry(t *testing.T) {
+	running := []AppInfo{{Name: "Trailing Space ", PID: 15}, {Name: "Foo, Inc. Helper", PID: 11}}
+	opts := Options{AllowedApps: []string{"Trailing Space"}, ReplaceDefaultAllowed: true}
+
+	got := targetAppsFromRunning(running, opts)
+	want := []AppInfo{{Name: "Foo, Inc. Helper", PID: 11}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets: got %+v want %+v", got, want)
+	}
+}
+
+func TestAppleScriptString(t *testing.T) {
+	cases := map[string]string{
+		"Safari":           `"Safari"`,
+		`Say "Hi"`:         `"Say \"Hi\""`,
+		`Back\slash`:       `"Back\\slash"`,
+		"Foo, Inc. Helper": `"Foo, Inc. Helper"`,
+	}
+	for input, want := range cases {
+		if got := appleScriptString(input); got != want {
+			t.Fatalf("appleScriptString(%q): got %s want %s", input, got, want)
+		}
+	}
+}
+
+func record(fields ...string) string {
+	return strings.Join(fields, macFieldSeparator) + macRecordSeparator
+}
+
+func TestAppMatcherMatch(t *testing.T) {
 	allowed := makeAllowedSet([]string{"Terminal"})
-	got := filterTargets(running, allowed)
-	want := []string{"Safari", "Slack"}
+	var got []MatchStatus
+	for _, app := range appInfos("terminal", "Safari") {
+		got = append(got, allowed.match(app).Status)
+	}
+	want := []MatchStatus{MatchAllowed, MatchTarget}
 	if !reflect.DeepEqual(got, want) {
-		t.Fatalf("unexpected targets: got %v want %v", got, want)
+		t.Fatalf("unexpected statuses: got %v want %v", got, want)
 	}
 }
 
@@ -132,7 +208,7 @@ func TestTargetAppsFromRunning(t *testing.T) {
 		DisallowedApps: []string{"Ghostty"},
 	}
 
-	got := targetAppsFromRunning(running, opts)
+	got := AppNames(targetAppsFromRunning(appInfos(running...), opts))
 	want := []string{"Ghostty", "Safari"}
 
 	if !reflect.DeepEqual(got, want) {
@@ -140,24 +216,145 @@ func TestTargetAppsFromRunning(t *testing.T) {
 	}
 }
 
+func TestQuitAppUsesBundleIDAndPID(t *testing.T) {
+	mock := &mockExecutor{
+		results: map[string]callResult{
+			"kill|-KILL|812": {output: []byte("kill: (812) - No such process"), err: errors.New("exit status 1")},
+		},
+	}
+	slack := AppInfo{Name: "Slack", BundleID: "com.tinyspeck.slackmacgap", PID: 812}
+
+	result := quitApp(mock, macPlatform{}, slack, EscalationPolicy{})
+	if result.Err != nil {
+		t.Fatalf("expected nil error, got %v", result.Err)
+	}
+	if result.ForceKill != StepNotRunning || result.BundleID != slack.BundleID {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	wantCalls := []string{
+		`osascript|-e|tell application id "com.tinyspeck.slackmacgap" to quit`,
+		"ps|-o|pid=|-p|812",
+		"kill|-KILL|812",
+	}
+	if !reflect.DeepEqual(mock.calls, wantCalls) {
+		t.Fatalf("unexpected calls: got %v want %v", mock.calls, wantCalls)
+	}
+}
+
 func TestQuitAppPkillNoProcessIsAccepted(t *testing.T) {
 	mock := &mockExecutor{
 		results: map[string]callResult{
-			"pkill|-x|Safari": {err: errors.New("exit status 1")},
+			"pkill|-KILL|-x|Safari": {err: errors.New("exit status 1")},
 		},
 	}
-	if err := quitApp(mock, "Safari"); err != nil {
-		t.Fatalf("expected nil error, got %v", err)
+	result := quitApp(mock, macPlatform{}, AppInfo{Name: "Safari"}, EscalationPolicy{})
+	if result.Err != nil {
+		t.Fatalf("expected nil error, got %v", result.Err)
+	}
+	if result.Graceful != StepOK || result.ForceKill != StepNotRunning {
+		t.Fatalf("unexpected outcomes: %+v", result)
 	}
 }
 
 func TestQuitAppPkillFailureWithOutput(t *testing.T) {
 	mock := &mockExecutor{
 		results: map[string]callResult{
-			"pkill|-x|Safari": {output: []byte("permission denied"), err: errors.New("exit status 3")},
+			"pkill|-KILL|-x|Safari": {output: []byte("permission denied"), err: errors.New("exit status 3")},
 		},
 	}
-	if err := quitApp(mock, "Safari"); err == nil {
+	result := quitApp(mock, macPlatform{}, AppInfo{Name: "Safari"}, EscalationPolicy{})
+	if result.Err == nil {
 		t.Fatal("expected error, got nil")
 	}
+	if result.ForceKill != StepFailed {
+		t.Fatalf("unexpected force-kill outcome: %s", result.ForceKill)
+	}
+}
+
+// slowPlatform records how many Quit calls overlap. Later apps finish first
+// so completion order differs from target order.
+type slowPlatform struct {
+	fakePlatform
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	delays    map[string]time.Duration
+}
+
+func (p *slowPlatform) Quit(executor Executor, app AppInfo) error {
+	p.mu.Lock()
+	p.active++
+	if p.active > p.maxActive {
+		p.maxActive = p.active
+	}
+	p.mu.Unlock()
+
+	time.Sleep(p.delays[app.Name])
+
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+	return p.fakePlatform.Quit(executor, app)
+}
+
+func TestQuitAppsKeepsTargetOrder(t *testing.T) {
+	targets := []string{"A", "B", "C", "D", "E", "F"}
+	platform := &slowPlatform{delays: map[string]time.Duration{
+		"A": 30 * time.Millisecond,
+		"B": 25 * time.Millisecond,
+		"C": 20 * time.Millisecond,
+		"D": 15 * time.Millisecond,
+		"E": 10 * time.Millisecond,
+		"F": 5 * time.Millisecond,
+	}}
+
+	results := quitApps(&mockExecutor{}, platform, appInfos(targets...), Options{Concurrency: 3})
+
+	got := make([]string, 0, len(results))
+	for _, result := range results {
+		got = append(got, result.App)
+	}
+	if !reflect.DeepEqual(got, targets) {
+		t.Fatalf("unexpected result order: got %v want %v", got, targets)
+	}
+}
+
+func TestQuitAppsBoundsConcurrency(t *testing.T) {
+	targets := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	delays := make(map[string]time.Duration, len(targets))
+	for _, app := range targets {
+		delays[app] = 10 * time.Millisecond
+	}
+
+	for _, limit := range []int{1, 2, 3} {
+		platform := &slowPlatform{delays: delays}
+		quitApps(&mockExecutor{}, platform, appInfos(targets...), Options{Concurrency: limit})
+		if platform.maxActive > limit {
+			t.Fatalf("concurrency %d exceeded: saw %d quits at once", limit, platform.maxActive)
+		}
+		if len(platform.quit) != len(targets) {
+			t.Fatalf("expected every app to be quit, got %v", platform.quit)
+		}
+	}
+}
+
+func TestExecuteWithOptionsParallel(t *testing.T) {
+	platform := &slowPlatform{
+		fakePlatform: fakePlatform{running: []string{"Slack", "Safari", "Music", "Notes"}},
+		delays:       map[string]time.Duration{"Music": 10 * time.Millisecond, "Notes": 10 * time.Millisecond},
+	}
+	useFakePlatform(t, platform)
+
+	results, err := ExecuteWithOptions(&mockExecutor{}, Options{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	want := []string{"Music", "Notes", "Safari", "Slack"}
+	if got := ClosedApps(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected closed apps: got %v want %v", got, want)
+	}
+	if platform.maxActive > 2 {
+		t.Fatalf("concurrency exceeded: %d", platform.maxActive)
+	}
 }